@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	layoutTag = "layout"
+)
+
+// tagParserFunc is a parser that can make use of the struct tag of the field being parsed
+type tagParserFunc func(string, reflect.StructTag) (interface{}, error)
+
+// builtinParsers holds the parsers for common standard library types. Parsers for pointer types are also used
+// for fields of the underlying type
+var builtinParsers = map[reflect.Type]tagParserFunc{
+	reflect.TypeOf(time.Time{}):      parseTime,
+	reflect.TypeOf(&time.Location{}): parseLocation,
+	reflect.TypeOf(&url.URL{}):       parseURL,
+	reflect.TypeOf(net.IP{}):         parseIP,
+	reflect.TypeOf(&net.IPNet{}):     parseIPNet,
+	reflect.TypeOf(&regexp.Regexp{}): parseRegexp,
+	reflect.TypeOf(os.FileMode(0)):   parseFileMode,
+}
+
+// parseTime parses a time using the layout tag, defaulting to RFC3339
+func parseTime(s string, tag reflect.StructTag) (interface{}, error) {
+	return time.Parse(getTag(tag, layoutTag, time.RFC3339), s)
+}
+
+func parseLocation(s string, _ reflect.StructTag) (interface{}, error) {
+	return time.LoadLocation(s)
+}
+
+func parseURL(s string, _ reflect.StructTag) (interface{}, error) {
+	return url.Parse(s)
+}
+
+func parseIP(s string, _ reflect.StructTag) (interface{}, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address %q", s)
+	}
+
+	return ip, nil
+}
+
+func parseIPNet(s string, _ reflect.StructTag) (interface{}, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
+func parseRegexp(s string, _ reflect.StructTag) (interface{}, error) {
+	return regexp.Compile(s)
+}
+
+// parseFileMode parses a file mode in octal notation, i.e. 0644 or 755
+func parseFileMode(s string, _ reflect.StructTag) (interface{}, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.FileMode(mode), nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"net/netip"
+	"reflect"
+)
+
+func init() {
+	builtinParsers[reflect.TypeOf(netip.Addr{})] = parseAddr
+	builtinParsers[reflect.TypeOf(netip.AddrPort{})] = parseAddrPort
+	builtinParsers[reflect.TypeOf(netip.Prefix{})] = parsePrefix
+}
+
+func parseAddr(s string, _ reflect.StructTag) (interface{}, error) {
+	return netip.ParseAddr(s)
+}
+
+func parseAddrPort(s string, _ reflect.StructTag) (interface{}, error) {
+	return netip.ParseAddrPort(s)
+}
+
+func parsePrefix(s string, _ reflect.StructTag) (interface{}, error) {
+	return netip.ParsePrefix(s)
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess_BuiltinNetip(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_ADDR", "::1"))
+	assert.NoError(t, os.Setenv("TEST_ADDR_PORT", "127.0.0.1:8080"))
+	assert.NoError(t, os.Setenv("TEST_PREFIX", "192.168.0.0/16"))
+
+	params := &struct {
+		Addr     netip.Addr     `env:"TEST_ADDR"`
+		AddrPort netip.AddrPort `env:"TEST_ADDR_PORT"`
+		Prefix   netip.Prefix   `env:"TEST_PREFIX"`
+		Unset    *netip.Addr    `env:"TEST_UNSET_ADDR"`
+	}{}
+
+	assert.NoError(t, Process(params))
+	assert.Equal(t, netip.MustParseAddr("::1"), params.Addr)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), params.AddrPort)
+	assert.Equal(t, netip.MustParsePrefix("192.168.0.0/16"), params.Prefix)
+	assert.Nil(t, params.Unset)
+
+	assert.NoError(t, os.Setenv("TEST_ADDR", "not-an-addr"))
+	assert.Error(t, Process(params))
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess_Builtins(t *testing.T) {
+	os.Clearenv()
+	for k, v := range map[string]string{
+		"TEST_TIME":     "2021-03-04T05:06:07Z",
+		"TEST_DATE":     "2021-03-04",
+		"TEST_URL":      "https://example.com/path?query=1",
+		"TEST_IP":       "192.168.0.1",
+		"TEST_CIDR":     "10.0.0.0/8",
+		"TEST_LOCATION": "UTC",
+		"TEST_REGEXP":   "^foo.*$",
+		"TEST_MODE":     "0644",
+	} {
+		assert.NoError(t, os.Setenv(k, v))
+	}
+
+	params := &struct {
+		Time     time.Time      `env:"TEST_TIME"`
+		Date     time.Time      `env:"TEST_DATE" layout:"2006-01-02"`
+		Dates    []time.Time    `env:"TEST_DATE" layout:"2006-01-02"`
+		DatePtr  *time.Time     `env:"TEST_DATE" layout:"2006-01-02"`
+		URL      url.URL        `env:"TEST_URL"`
+		URLPtr   *url.URL       `env:"TEST_URL"`
+		IP       net.IP         `env:"TEST_IP"`
+		CIDR     net.IPNet      `env:"TEST_CIDR"`
+		CIDRPtr  *net.IPNet     `env:"TEST_CIDR"`
+		Location *time.Location `env:"TEST_LOCATION"`
+		Regexp   *regexp.Regexp `env:"TEST_REGEXP"`
+		Mode     os.FileMode    `env:"TEST_MODE"`
+		UnsetURL *url.URL       `env:"TEST_UNSET_URL"`
+	}{}
+
+	assert.NoError(t, Process(params))
+
+	expectedURL, _ := url.Parse("https://example.com/path?query=1")
+	_, expectedCIDR, _ := net.ParseCIDR("10.0.0.0/8")
+
+	assert.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), params.Time)
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), params.Date)
+	assert.Equal(t, []time.Time{time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)}, params.Dates)
+	if assert.NotNil(t, params.DatePtr) {
+		assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), *params.DatePtr)
+	}
+	assert.Equal(t, *expectedURL, params.URL)
+	assert.Equal(t, expectedURL, params.URLPtr)
+	assert.Equal(t, net.ParseIP("192.168.0.1"), params.IP)
+	assert.Equal(t, *expectedCIDR, params.CIDR)
+	assert.Equal(t, expectedCIDR, params.CIDRPtr)
+	assert.Equal(t, time.UTC, params.Location)
+	assert.Equal(t, regexp.MustCompile("^foo.*$"), params.Regexp)
+	assert.Equal(t, os.FileMode(0644), params.Mode)
+	assert.Nil(t, params.UnsetURL)
+}
+
+func TestProcess_BuiltinErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		value  string
+		params interface{}
+	}{{
+		name:  "Time",
+		value: "2021-03-04",
+		params: &struct {
+			Value time.Time `env:"TEST_VALUE"`
+		}{},
+	}, {
+		name:  "IP",
+		value: "not-an-ip",
+		params: &struct {
+			Value net.IP `env:"TEST_VALUE"`
+		}{},
+	}, {
+		name:  "CIDR",
+		value: "10.0.0.1",
+		params: &struct {
+			Value net.IPNet `env:"TEST_VALUE"`
+		}{},
+	}, {
+		name:  "Regexp",
+		value: "(",
+		params: &struct {
+			Value *regexp.Regexp `env:"TEST_VALUE"`
+		}{},
+	}, {
+		name:  "FileMode",
+		value: "0999",
+		params: &struct {
+			Value os.FileMode `env:"TEST_VALUE"`
+		}{},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			assert.NoError(t, os.Setenv("TEST_VALUE", tc.value))
+
+			assert.Error(t, Process(tc.params))
+		})
+	}
+}
@@ -2,12 +2,11 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
-
-	"github.com/hashicorp/go-multierror"
 )
 
 // Source represents a configuration source
@@ -19,37 +18,160 @@ type Source interface {
 	Process(map[string][]Parameter) error
 }
 
-func process(params interface{}, paramMap map[string]map[string][]Parameter, sourceKeys []string) error {
-	var errs *multierror.Error
+// ContextSource represents a configuration source that honours cancellation and deadlines of a context
+type ContextSource interface {
+	Source
+
+	// ProcessContext processes a given set of parameters, returning an error if one occurred or if
+	// the context is done before processing completes
+	ProcessContext(context.Context, map[string][]Parameter) error
+}
+
+// ValuesSource represents a configuration source that can set map and struct fields as a whole from a set of named
+// values, via ValuesParameter. Nested structs are only set as a whole if their tag value for the source is a values
+// key, otherwise their fields are handled individually
+type ValuesSource interface {
+	Source
+
+	// IsValuesKey returns true if the tag value loads a set of named values rather than a single value
+	IsValuesKey(tagValue string) bool
+}
+
+// AdaptSource returns a ContextSource for the given source. If the source already implements ContextSource it
+// is returned as is, otherwise it is wrapped so that the context is checked before the source is processed
+func AdaptSource(s Source) ContextSource {
+	if cs, ok := s.(ContextSource); ok {
+		return cs
+	}
+
+	return &sourceAdapter{s}
+}
+
+type sourceAdapter struct {
+	Source
+}
+
+// ProcessContext processes the wrapped source if the context is not yet done
+func (a *sourceAdapter) ProcessContext(ctx context.Context, paramMap map[string][]Parameter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return a.Process(paramMap)
+}
+
+// Option configures a Processor
+type Option func(*Processor)
+
+// WithPrecedence sets the order in which sources are consulted for fields that have tags for more than one
+// source, highest precedence first. Sources with tag keys that are not listed are consulted afterwards, in
+// the order they were passed in
+func WithPrecedence(tagKeys ...string) Option {
+	return func(p *Processor) {
+		p.precedence = tagKeys
+	}
+}
+
+// Processor processes values from various sources using a set of options
+type Processor struct {
+	precedence []string
+	parsers    map[reflect.Type]ParserFunc
+}
+
+// New creates a new processor with the given options
+func New(opts ...Option) *Processor {
+	p := &Processor{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// sortSources returns the sources sorted by precedence, highest precedence first
+func (p *Processor) sortSources(sources []Source) []Source {
+	sorted := make([]Source, 0, len(sources))
+	used := make([]bool, len(sources))
+
+	for _, key := range p.precedence {
+		for i, s := range sources {
+			if !used[i] && s.TagKey() == key {
+				sorted = append(sorted, s)
+				used[i] = true
+			}
+		}
+	}
+
+	for i, s := range sources {
+		if !used[i] {
+			sorted = append(sorted, s)
+		}
+	}
+
+	return sorted
+}
+
+// processState holds the state of a single call to process
+type processState struct {
+	paramMap map[string]map[string][]Parameter
+
+	// sourceKeys holds the tag keys of all sources in precedence order
+	sourceKeys []string
+
+	parsers map[reflect.Type]ParserFunc
+
+	// resolvers holds the sources that implement KeyResolver, by tag key
+	resolvers map[string]KeyResolver
+
+	// valuesSources holds the sources that implement ValuesSource, by tag key
+	valuesSources map[string]ValuesSource
+
+	fields []*field
 
+	errs *ProcessError
+}
+
+func (ps *processState) setterOptions(tag reflect.StructTag) setterOptions {
+	return setterOptions{
+		tag:     tag,
+		parsers: ps.parsers,
+	}
+}
+
+// process walks the given struct pointer, registering a parameter for every field tagged for one of the sources.
+// The path is the path to the struct from the root struct, and if onSet is not nil it is called whenever a value
+// is set on one of the fields
+func (ps *processState) process(params interface{}, path string, onSet func()) {
 	typeOf := reflect.TypeOf(params)
 	elem := reflect.ValueOf(params).Elem()
 
 	numFields := elem.NumField()
 	for i := 0; i < numFields; i++ {
 		field := elem.Field(i)
+		sf := typeOf.Elem().Field(i)
+		fieldPath := path + sf.Name
 
 		// handle interface
 		if field.Kind() == reflect.Interface && !field.IsNil() {
-			if err := process(field.Interface(), paramMap, sourceKeys); err != nil {
-				errs = multierror.Append(errs, fmt.Errorf("error processing interface: %w", err))
-			}
-
 			// interfaces are handled recursively, continue
+			ps.process(field.Interface(), fieldPath+".", onSet)
 			continue
 		}
 
-		if field.Kind() == reflect.Struct {
-			val := field.Addr()
-			if err := process(val.Interface(), paramMap, sourceKeys); err != nil {
-				errs = multierror.Append(errs, fmt.Errorf("error processing struct: %w", err))
-			}
-
+		if field.Kind() == reflect.Struct && !ps.setsWhole(sf.Tag, field.Type()) {
 			// structs are handled recursively, continue
+			ps.process(field.Addr().Interface(), fieldPath+".", onSet)
+			continue
+		}
+
+		if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct &&
+			!ps.setsWhole(sf.Tag, field.Type().Elem()) {
+			// struct pointers are handled recursively, continue
+			val, onStructSet := structPtr(field, onSet)
+			ps.process(val, fieldPath+".", onStructSet)
 			continue
 		}
 
-		sf := typeOf.Elem().Field(i)
 		ignoreValue, ok := sf.Tag.Lookup(ignoreTag)
 		if ok {
 			ignoreValueBool, err := strconv.ParseBool(ignoreValue)
@@ -60,75 +182,190 @@ func process(params interface{}, paramMap map[string]map[string][]Parameter, sou
 			}
 		}
 
-		setFn, err := getSetter(field)
+		opts := ps.setterOptions(sf.Tag)
+		valuesFn := getValuesSetter(field, opts)
+
+		setFn, err := getSetter(field, opts)
+		if err != nil && valuesFn != nil {
+			setFn, err = valuesOnlySetter(field.Type()), nil
+		}
+
 		if err != nil {
-			errs = multierror.Append(errs, fmt.Errorf("error getting set function: %w", err))
+			ps.errs.append(&FieldError{
+				Field: sf.Name,
+				Path:  fieldPath,
+				Kind:  Unsupported,
+				Err:   err,
+			})
 			continue
 		}
 
-		var foundHandler bool
+		fld := newField(sf, fieldPath, len(ps.fields), setFn, onSet)
+		fld.valuesFn = valuesFn
+		fld.listFn = getListSetter(field, opts)
 
-		// iterate through source tag keys and populate parameter map with parameter
-		// for any found tags
-		for tagKey, sourceParams := range paramMap {
+		// iterate through source tag keys in precedence order and populate the parameter map with a
+		// parameter for every found tag, the first source to provide a value for the field wins
+		for _, tagKey := range ps.sourceKeys {
 			tagValue, ok := sf.Tag.Lookup(tagKey)
 			if !ok {
 				continue
 			}
 
-			foundHandler = true
+			sourceParams := ps.paramMap[tagKey]
+			sourceParams[tagValue] = append(sourceParams[tagValue], fld.newParameter(tagKey, tagValue))
+		}
 
-			param := newParameter(sf, setFn, tagKey, tagValue)
-			sourceParams[tagValue] = append(sourceParams[tagValue], param)
+		if len(fld.params) > 0 {
+			ps.fields = append(ps.fields, fld)
+			continue
+		}
 
-			// since map ordering is non-deterministic, the docs will call out potential
-			// strange behavior if tags from multiple sources are specified on the same field.
-			//
-			// to prevent unnecessary lookups in a given remote source, we'll just assume there's
-			// only one source tag on a parameter and break after finding one
-			break
+		required, _ := strconv.ParseBool(sf.Tag.Get(requiredTag))
+		if !required {
+			continue
 		}
 
-		if !foundHandler {
-			required, _ := strconv.ParseBool(sf.Tag.Get(requiredTag))
-			if !required {
-				continue
-			}
+		ps.errs.append(&FieldError{
+			Field: sf.Name,
+			Path:  fieldPath,
+			Kind:  Missing,
+			Err: fmt.Errorf(
+				"did not specify a struct tag for one of the provided sources: %s",
+				strings.Join(ps.sourceKeys, ", "),
+			),
+		})
+	}
+}
 
-			errs = multierror.Append(
-				errs,
-				fmt.Errorf(
-					"error: the field %s was marked as required, but did not specify a struct tag for one of the provided sources: %s",
-					sf.Name,
-					strings.Join(sourceKeys, ", "),
-				),
-			)
+// setsWhole returns true if a struct field of the given type is set as a whole rather than having its fields handled
+// individually. That is the case if the tag has a values key for one of the sources, or if the tag has a key for
+// one of the sources and the type has a registered parser or decodes itself. Untagged structs are always handled
+// individually
+func (ps *processState) setsWhole(tag reflect.StructTag, typ reflect.Type) bool {
+	tagged := false
+
+	for _, key := range ps.sourceKeys {
+		tagValue, ok := tag.Lookup(key)
+		if !ok {
+			continue
 		}
+
+		if vs, ok := ps.valuesSources[key]; ok && vs.IsValuesKey(tagValue) {
+			return true
+		}
+
+		tagged = true
 	}
 
-	return errs.ErrorOrNil()
+	return tagged && ps.setterOptions("").hasCustomSetter(typ)
 }
 
-func processSources(sources []Source, paramMap map[string]map[string][]Parameter) error {
-	var errs *multierror.Error
+// resolveKey returns the key the source of the parameter looks up
+func (ps *processState) resolveKey(p *parameter) string {
+	if r, ok := ps.resolvers[p.tagKey]; ok {
+		return r.ResolveKey(p.tagValue)
+	}
+
+	return p.tagValue
+}
+
+// structPtr returns the struct pointer to process for a struct pointer field, along with the function to call
+// when a value is set. Nil pointers are only allocated once a value is set on one of the nested fields, so that
+// they stay nil if nothing was configured
+func structPtr(field reflect.Value, onSet func()) (interface{}, func()) {
+	if !field.IsNil() {
+		return field.Interface(), onSet
+	}
+
+	val := reflect.New(field.Type().Elem())
+
+	return val.Interface(), func() {
+		if field.IsNil() {
+			field.Set(val)
+		}
+
+		if onSet != nil {
+			onSet()
+		}
+	}
+}
 
+func (ps *processState) processSources(ctx context.Context, sources []Source) {
 	for _, s := range sources {
-		params, ok := paramMap[s.TagKey()]
+		params, ok := ps.paramMap[s.TagKey()]
 		if !ok || len(params) == 0 {
 			continue
 		}
 
-		errs = multierror.Append(errs, s.Process(params))
+		// stop processing any further sources once the context is done
+		if err := ctx.Err(); err != nil {
+			ps.errs.append(err)
+			break
+		}
+
+		ps.errs.append(AdaptSource(s).ProcessContext(ctx, params))
 	}
 
-	return errs.ErrorOrNil()
+	// resolve any fields that no source handled, i.e. when a source failed or skipped a parameter
+	for _, f := range ps.fields {
+		if f.done {
+			continue
+		}
+
+		ps.errs.append(f.noValue())
+	}
 }
 
 // Process handles processing values from various sources
 func Process(params interface{}, sources ...Source) error {
+	return New().Process(params, sources...)
+}
+
+// ProcessContext handles processing values from various sources, stopping early if the context is done. Sources
+// that implement ContextSource are passed the context, other sources are adapted via AdaptSource
+func ProcessContext(ctx context.Context, params interface{}, sources ...Source) error {
+	return New().ProcessContext(ctx, params, sources...)
+}
+
+// Process handles processing values from various sources
+func (p *Processor) Process(params interface{}, sources ...Source) error {
+	return p.ProcessContext(context.Background(), params, sources...)
+}
+
+// ProcessContext handles processing values from various sources, stopping early if the context is done.
+//
+// If a field has tags for more than one source, the sources are consulted in order of precedence, which is the
+// order the sources were passed in unless overridden via WithPrecedence. The first source to provide a value
+// for the field wins, falling through to the next source when one has no value
+func (p *Processor) ProcessContext(ctx context.Context, params interface{}, sources ...Source) error {
+	_, err := p.process(ctx, params, sources)
+	return err
+}
+
+// process processes the values, returning the state so that details about the processed fields can be reported
+func (p *Processor) process(ctx context.Context, params interface{}, sources []Source) (*processState, error) {
+	ps, sources, err := p.newProcessState(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.process(params, "", nil)
+	if err := ps.errs.errorOrNil(); err != nil {
+		return ps, err
+	}
+
+	ps.processSources(ctx, sources)
+	return ps, ps.errs.errorOrNil()
+}
+
+// newProcessState creates the state for a single call to process, returning the sources to process in order
+// of precedence
+func (p *Processor) newProcessState(sources []Source) (*processState, []Source, error) {
 	hasEnvSource := false
 	for _, s := range sources {
-		if _, ok := s.(*EnvSource); ok {
+		// any source using the env tag key, such as DotEnvSource, takes the place of the default EnvSource
+		if s.TagKey() == (&EnvSource{}).TagKey() {
 			hasEnvSource = true
 			break
 		}
@@ -139,23 +376,35 @@ func Process(params interface{}, sources ...Source) error {
 		sources = append(sources, &EnvSource{})
 	}
 
-	paramMap := make(map[string]map[string][]Parameter)
-	sourceKeys := make([]string, len(sources))
+	sources = p.sortSources(sources)
+
+	ps := &processState{
+		paramMap:      make(map[string]map[string][]Parameter),
+		sourceKeys:    make([]string, len(sources)),
+		parsers:       p.parsers,
+		resolvers:     make(map[string]KeyResolver),
+		valuesSources: make(map[string]ValuesSource),
+		errs:          &ProcessError{},
+	}
 
 	for i, s := range sources {
 		key := s.TagKey()
 
-		if _, ok := paramMap[key]; ok {
-			return fmt.Errorf("error: multiple sources provided with the tag key %s", key)
+		if _, ok := ps.paramMap[key]; ok {
+			return nil, nil, fmt.Errorf("error: multiple sources provided with the tag key %s", key)
 		}
 
-		paramMap[key] = make(map[string][]Parameter)
-		sourceKeys[i] = key
-	}
+		ps.paramMap[key] = make(map[string][]Parameter)
+		ps.sourceKeys[i] = key
 
-	if err := process(params, paramMap, sourceKeys); err != nil {
-		return err
+		if r, ok := s.(KeyResolver); ok {
+			ps.resolvers[key] = r
+		}
+
+		if vs, ok := s.(ValuesSource); ok {
+			ps.valuesSources[key] = vs
+		}
 	}
 
-	return processSources(sources, paramMap)
+	return ps, sources, nil
 }
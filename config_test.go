@@ -1,11 +1,19 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/fatih/structs"
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -224,3 +232,663 @@ func TestProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessContext(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_ENV_STR", "test env string"))
+
+	t.Run("Normal", func(t *testing.T) {
+		params := &struct {
+			TestEnvStr string `env:"TEST_ENV_STR" required:"true"`
+		}{}
+
+		assert.NoError(t, ProcessContext(context.Background(), params))
+		assert.Equal(t, "test env string", params.TestEnvStr)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		params := &struct {
+			TestEnvStr string `env:"TEST_ENV_STR" required:"true"`
+		}{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := ProcessContext(ctx, params)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Empty(t, params.TestEnvStr)
+	})
+}
+
+func TestAdaptSource(t *testing.T) {
+	env := &EnvSource{}
+	assert.Equal(t, "env", AdaptSource(env).TagKey())
+
+	mock := &mockSource{tagKey: "mock"}
+	adapted := AdaptSource(mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := adapted.ProcessContext(ctx, map[string][]Parameter{"key": {&mockParameter{}}})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Nil(t, mock.processInput)
+}
+
+func TestProcess_Precedence(t *testing.T) {
+	type params struct {
+		Str      string `env:"TEST_STR" mock:"test-str"`
+		Fallback string `env:"TEST_FALLBACK" mock:"test-fallback"`
+		Default  string `env:"TEST_DEFAULT" mock:"test-default" default:"default"`
+		Required string `env:"TEST_REQUIRED" mock:"test-required" required:"true"`
+	}
+
+	testCases := []struct {
+		name      string
+		opts      []Option
+		envFirst  bool
+		expected  params
+		expectErr bool
+	}{{
+		name:     "SourceOrderEnvFirst",
+		envFirst: true,
+		expected: params{
+			Str:      "env",
+			Fallback: "mock",
+			Default:  "default",
+			Required: "mock",
+		},
+	}, {
+		name:     "SourceOrderMockFirst",
+		envFirst: false,
+		expected: params{
+			Str:      "mock",
+			Fallback: "mock",
+			Default:  "default",
+			Required: "mock",
+		},
+	}, {
+		name:     "WithPrecedence",
+		opts:     []Option{WithPrecedence("env")},
+		envFirst: false,
+		expected: params{
+			Str:      "env",
+			Fallback: "mock",
+			Default:  "default",
+			Required: "mock",
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			assert.NoError(t, os.Setenv("TEST_STR", "env"))
+
+			env := &EnvSource{}
+			mock := &mockSource{
+				tagKey: "mock",
+				vars: map[string]string{
+					"test-str":      "mock",
+					"test-fallback": "mock",
+					"test-required": "mock",
+				},
+			}
+
+			sources := []Source{mock, env}
+			if tc.envFirst {
+				sources = []Source{env, mock}
+			}
+
+			var p params
+			assert.NoError(t, New(tc.opts...).Process(&p, sources...))
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+
+	t.Run("RequiredMissing", func(t *testing.T) {
+		os.Clearenv()
+
+		var p params
+		err := Process(&p, &mockSource{tagKey: "mock"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "key test-required in source mock, key TEST_REQUIRED in source env")
+		assert.Equal(t, "default", p.Default)
+	})
+}
+
+func TestProcess_Pointers(t *testing.T) {
+	type nested struct {
+		Str string `env:"TEST_NESTED_STR"`
+	}
+
+	params := struct {
+		IntSet      *int           `env:"TEST_INT_SET"`
+		IntUnset    *int           `env:"TEST_INT_UNSET"`
+		DurDefault  *time.Duration `env:"TEST_DUR_DEFAULT" default:"5s"`
+		StrSlice    *[]string      `env:"TEST_STR_SLICE"`
+		NestedSet   *nested
+		NestedUnset *struct {
+			Inner *nested
+		}
+		NestedExisting *nested
+	}{
+		NestedExisting: &nested{Str: "existing"},
+	}
+
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_INT_SET", "0"))
+	assert.NoError(t, os.Setenv("TEST_STR_SLICE", "foo,bar"))
+
+	assert.NoError(t, Process(&params))
+
+	if assert.NotNil(t, params.IntSet) {
+		assert.Equal(t, 0, *params.IntSet)
+	}
+	assert.Nil(t, params.IntUnset)
+	if assert.NotNil(t, params.DurDefault) {
+		assert.Equal(t, 5*time.Second, *params.DurDefault)
+	}
+	if assert.NotNil(t, params.StrSlice) {
+		assert.Equal(t, []string{"foo", "bar"}, *params.StrSlice)
+	}
+	assert.Nil(t, params.NestedSet)
+	assert.Nil(t, params.NestedUnset)
+	assert.Equal(t, &nested{Str: "existing"}, params.NestedExisting)
+
+	assert.NoError(t, os.Setenv("TEST_NESTED_STR", "nested"))
+	assert.NoError(t, Process(&params))
+
+	assert.Equal(t, &nested{Str: "nested"}, params.NestedSet)
+	if assert.NotNil(t, params.NestedUnset) && assert.NotNil(t, params.NestedUnset.Inner) {
+		assert.Equal(t, "nested", params.NestedUnset.Inner.Str)
+	}
+	assert.Equal(t, &nested{Str: "nested"}, params.NestedExisting)
+}
+
+func TestProcess_Maps(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_LABELS", "team:platform,env:prod"))
+	assert.NoError(t, os.Setenv("TEST_WEIGHTS", "a=1;b=2"))
+	assert.NoError(t, os.Setenv("TEST_INVALID", "a:1,b"))
+
+	t.Run("Normal", func(t *testing.T) {
+		params := &struct {
+			Labels  map[string]string `env:"TEST_LABELS"`
+			Weights map[string]int    `env:"TEST_WEIGHTS" separator:";" kvseparator:"="`
+			Empty   map[string]string `env:"TEST_EMPTY"`
+			Default map[int]bool      `env:"TEST_DEFAULT" default:"1:true,2:false"`
+		}{}
+
+		assert.NoError(t, Process(params))
+		assert.Equal(t, map[string]string{"team": "platform", "env": "prod"}, params.Labels)
+		assert.Equal(t, map[string]int{"a": 1, "b": 2}, params.Weights)
+		assert.Nil(t, params.Empty)
+		assert.Equal(t, map[int]bool{1: true, 2: false}, params.Default)
+	})
+
+	t.Run("InvalidEntry", func(t *testing.T) {
+		params := &struct {
+			Invalid map[string]int `env:"TEST_INVALID"`
+		}{}
+
+		assert.Error(t, Process(params))
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		params := &struct {
+			Unsupported map[string]chan int `env:"TEST_LABELS"`
+		}{}
+
+		assert.Error(t, Process(params))
+	})
+}
+
+// mockValuesSource sets every parameter from the set of values stored for its key
+type mockValuesSource struct {
+	values map[string]map[string]string
+}
+
+func (m *mockValuesSource) TagKey() string {
+	return "values"
+}
+
+func (m *mockValuesSource) IsValuesKey(string) bool {
+	return true
+}
+
+func (m *mockValuesSource) Process(input map[string][]Parameter) error {
+	for k, params := range input {
+		for _, p := range params {
+			if err := p.(ValuesParameter).SetValues(m.values[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestProcess_Values(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_FLAGS", "env:true"))
+
+	type database struct {
+		Host    string
+		Port    int `values:"port-number"`
+		Replica *struct {
+			Host string
+		}
+		Options map[string]string
+	}
+
+	src := &mockValuesSource{
+		values: map[string]map[string]string{
+			"flags": {
+				"new-checkout": "true",
+				"dark-mode":    "false",
+			},
+			"db": {
+				"host":             "db.local",
+				"PORT-NUMBER":      "5432",
+				"replica/host":     "replica.local",
+				"options/sslmode":  "require",
+				"options/timezone": "UTC",
+				"unknown":          "ignored",
+			},
+			"invalid": {
+				"port-number": "abc",
+			},
+		},
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		params := &struct {
+			Flags    map[string]bool `values:"flags" env:"TEST_FLAGS"`
+			Database database        `values:"db"`
+			Pointer  *database       `values:"db"`
+			Unset    *database       `values:"unset"`
+			Empty    map[string]bool `values:"empty" env:"TEST_FLAGS"`
+		}{}
+
+		assert.NoError(t, Process(params, src))
+		assert.Equal(t, map[string]bool{"new-checkout": true, "dark-mode": false}, params.Flags)
+
+		expected := database{
+			Host: "db.local",
+			Port: 5432,
+			Replica: &struct {
+				Host string
+			}{Host: "replica.local"},
+			Options: map[string]string{"sslmode": "require", "timezone": "UTC"},
+		}
+		assert.Equal(t, expected, params.Database)
+		assert.Equal(t, &expected, params.Pointer)
+		assert.Nil(t, params.Unset)
+
+		// falls through to the next source when there are no values
+		assert.Equal(t, map[string]bool{"env": true}, params.Empty)
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		params := &struct {
+			Database database `values:"invalid"`
+		}{}
+
+		err := Process(params, src)
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+	})
+
+	t.Run("NestedTagged", func(t *testing.T) {
+		assert.NoError(t, os.Setenv("TEST_DB", "ignored"))
+		assert.NoError(t, os.Setenv("TEST_DB_HOST", "db.local"))
+
+		// structs tagged for sources that don't load a set of values have their fields handled individually
+		params := &struct {
+			Database struct {
+				Host string `env:"TEST_DB_HOST"`
+			} `env:"TEST_DB"`
+			Pointer *struct {
+				Host string `env:"TEST_DB_HOST"`
+			} `env:"TEST_DB"`
+		}{}
+
+		assert.NoError(t, Process(params, src))
+		assert.Equal(t, "db.local", params.Database.Host)
+		if assert.NotNil(t, params.Pointer) {
+			assert.Equal(t, "db.local", params.Pointer.Host)
+		}
+	})
+}
+
+// mockListSource sets every parameter from the list stored for its key, marking secret keys as secret
+type mockListSource struct {
+	lists   map[string][]string
+	secrets map[string]bool
+	types   map[string]reflect.Type
+}
+
+func (m *mockListSource) TagKey() string {
+	return "list"
+}
+
+func (m *mockListSource) Process(input map[string][]Parameter) error {
+	for k, params := range input {
+		for _, p := range params {
+			m.types[k] = p.(TypedParameter).Type()
+
+			if m.secrets[k] {
+				p.(SecretParameter).MarkSecret()
+			}
+
+			if err := p.(ListParameter).SetList(m.lists[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestProcess_Lists(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_HOSTS", "env"))
+
+	src := &mockListSource{
+		lists: map[string][]string{
+			"hosts":   {"a;1", "b;2"},
+			"ports":   {"80", "443"},
+			"joined":  {"a", "b"},
+			"invalid": {"1", "abc"},
+		},
+		secrets: map[string]bool{
+			"invalid": true,
+		},
+		types: make(map[string]reflect.Type),
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		params := &struct {
+			Hosts  []string `list:"hosts" separator:";"`
+			Ports  []int    `list:"ports"`
+			Joined string   `list:"joined"`
+			Empty  []string `list:"empty" env:"TEST_HOSTS"`
+		}{}
+
+		assert.NoError(t, Process(params, src))
+		assert.Equal(t, []string{"a;1", "b;2"}, params.Hosts)
+		assert.Equal(t, []int{80, 443}, params.Ports)
+		assert.Equal(t, "a,b", params.Joined)
+		assert.Equal(t, []string{"env"}, params.Empty)
+		assert.Equal(t, reflect.TypeOf([]int{}), src.types["ports"])
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		params := &struct {
+			Invalid []int `list:"invalid"`
+		}{}
+
+		err := Process(params, src)
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		assert.NotContains(t, err.Error(), "abc")
+	})
+}
+
+// mockErrorSource rejects every parameter with its error
+type mockErrorSource struct {
+	err error
+}
+
+func (m *mockErrorSource) TagKey() string {
+	return "reject"
+}
+
+func (m *mockErrorSource) Process(input map[string][]Parameter) error {
+	var errs *multierror.Error
+
+	for _, params := range input {
+		for _, p := range params {
+			errs = multierror.Append(errs, p.(ErrorParameter).SetError(m.err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func TestProcess_SetError(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_FALLBACK", "env"))
+
+	testErr := errors.New("test error")
+	params := &struct {
+		Required string `reject:"required" required:"true"`
+		Default  string `reject:"default" default:"default"`
+	}{}
+
+	report, err := ProcessWithReport(params, &mockErrorSource{err: testErr})
+	assert.True(t, errors.Is(err, testErr))
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+	assert.False(t, errors.Is(err, ErrMissingValue))
+	assert.Empty(t, params.Default)
+
+	var processErr *ProcessError
+	if assert.True(t, errors.As(err, &processErr)) {
+		assert.Len(t, processErr.Fields(), 2)
+	}
+
+	if assert.Len(t, report.Fields, 2) {
+		assert.False(t, report.Fields[1].Default)
+		assert.Equal(t, "reject", report.Fields[1].Source)
+	}
+
+	// higher precedence sources win over a rejected value
+	fallback := &struct {
+		Field string `env:"TEST_FALLBACK" reject:"field"`
+	}{}
+
+	assert.NoError(t, New(WithPrecedence("env")).Process(fallback, &mockErrorSource{err: testErr}))
+	assert.Equal(t, "env", fallback.Field)
+}
+
+type testLevel int
+
+func (l *testLevel) Decode(s string) error {
+	switch s {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+
+	return nil
+}
+
+type testJSON struct {
+	Value string
+}
+
+func (j *testJSON) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	j.Value = s
+	return nil
+}
+
+type testJSONObject struct {
+	A int
+}
+
+func (o *testJSONObject) UnmarshalJSON(b []byte) error {
+	type plain testJSONObject
+	return json.Unmarshal(b, (*plain)(o))
+}
+
+func TestProcess_Decoders(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_LEVEL", "info"))
+	assert.NoError(t, os.Setenv("TEST_IP", "10.0.0.1"))
+	assert.NoError(t, os.Setenv("TEST_BIG", "123456789012345678901234567890"))
+	assert.NoError(t, os.Setenv("TEST_JSON", "plain"))
+	assert.NoError(t, os.Setenv("TEST_TIME", "2020-01-02T03:04:05Z"))
+	assert.NoError(t, os.Setenv("TEST_LEVELS", "debug,info"))
+	assert.NoError(t, os.Setenv("TEST_JSON_NUMBER", "123"))
+	assert.NoError(t, os.Setenv("TEST_JSON_NULL", "null"))
+	assert.NoError(t, os.Setenv("TEST_JSON_OBJECT", `{"A": 1}`))
+
+	params := &struct {
+		Level     testLevel   `env:"TEST_LEVEL"`
+		IP        net.IP      `env:"TEST_IP"`
+		Big       *big.Int    `env:"TEST_BIG"`
+		JSON      testJSON    `env:"TEST_JSON"`
+		Time      time.Time   `env:"TEST_TIME"`
+		Levels    []testLevel `env:"TEST_LEVELS"`
+		UnsetTime *time.Time  `env:"TEST_UNSET_TIME"`
+
+		JSONNumber testJSON       `env:"TEST_JSON_NUMBER"`
+		JSONNull   testJSON       `env:"TEST_JSON_NULL"`
+		JSONObject testJSONObject `env:"TEST_JSON_OBJECT"`
+	}{}
+
+	assert.NoError(t, Process(params))
+	assert.Equal(t, testLevel(2), params.Level)
+	assert.Equal(t, net.ParseIP("10.0.0.1"), params.IP)
+	assert.Equal(t, "123456789012345678901234567890", params.Big.String())
+	assert.Equal(t, "plain", params.JSON.Value)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), params.Time)
+	assert.Equal(t, []testLevel{1, 2}, params.Levels)
+	assert.Nil(t, params.UnsetTime)
+
+	// values are passed to json unmarshalers as strings first, falling back to raw json
+	assert.Equal(t, "123", params.JSONNumber.Value)
+	assert.Equal(t, "null", params.JSONNull.Value)
+	assert.Equal(t, testJSONObject{A: 1}, params.JSONObject)
+
+	assert.NoError(t, os.Setenv("TEST_LEVEL", "unknown"))
+	assert.Error(t, Process(params))
+}
+
+// EmbeddedJSON is exported so that it can be embedded, promoting its UnmarshalJSON method
+type EmbeddedJSON struct {
+	A int
+}
+
+func (e *EmbeddedJSON) UnmarshalJSON(b []byte) error {
+	type plain EmbeddedJSON
+	return json.Unmarshal(b, (*plain)(e))
+}
+
+func TestProcess_DecodersUntagged(t *testing.T) {
+	os.Clearenv()
+
+	// untagged structs have their fields handled individually, even if they implement a decoding interface
+	params := &struct {
+		Object  testJSONObject
+		Pointer *testJSONObject
+		Nested  struct {
+			EmbeddedJSON
+			Required string `env:"TEST_REQUIRED" required:"true"`
+		}
+	}{}
+
+	err := Process(params)
+	assert.True(t, errors.Is(err, ErrMissingValue))
+
+	assert.NoError(t, os.Setenv("TEST_REQUIRED", "required"))
+	assert.NoError(t, Process(params))
+	assert.Equal(t, "required", params.Nested.Required)
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func parseTestPoint(s string) (interface{}, error) {
+	var p testPoint
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+type testRegistered string
+
+func TestProcess_Parsers(t *testing.T) {
+	RegisterParser(reflect.TypeOf(testRegistered("")), func(s string) (interface{}, error) {
+		return testRegistered("global-" + s), nil
+	})
+
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_POINT", "1,2"))
+	assert.NoError(t, os.Setenv("TEST_REGISTERED", "value"))
+
+	type params struct {
+		Point      testPoint      `env:"TEST_POINT"`
+		PointPtr   *testPoint     `env:"TEST_POINT"`
+		Points     []testPoint    `env:"TEST_POINT" separator:";"`
+		Unset      *testPoint     `env:"TEST_UNSET"`
+		Registered testRegistered `env:"TEST_REGISTERED"`
+	}
+
+	t.Run("Global", func(t *testing.T) {
+		var p struct {
+			Registered testRegistered `env:"TEST_REGISTERED"`
+		}
+
+		assert.NoError(t, Process(&p))
+		assert.Equal(t, testRegistered("global-value"), p.Registered)
+	})
+
+	t.Run("WithParsers", func(t *testing.T) {
+		var p params
+		processor := New(WithParsers(map[reflect.Type]ParserFunc{
+			reflect.TypeOf(&testPoint{}): parseTestPoint,
+			reflect.TypeOf(testRegistered("")): func(s string) (interface{}, error) {
+				return testRegistered("local-" + s), nil
+			},
+		}))
+
+		assert.NoError(t, processor.Process(&p))
+		assert.Equal(t, testPoint{1, 2}, p.Point)
+		assert.Equal(t, &testPoint{1, 2}, p.PointPtr)
+		assert.Equal(t, []testPoint{{1, 2}}, p.Points)
+		assert.Nil(t, p.Unset)
+		assert.Equal(t, testRegistered("local-value"), p.Registered)
+	})
+
+	t.Run("InvalidType", func(t *testing.T) {
+		var p struct {
+			Registered testRegistered `env:"TEST_REGISTERED"`
+		}
+
+		processor := New(WithParsers(map[reflect.Type]ParserFunc{
+			reflect.TypeOf(testRegistered("")): func(s string) (interface{}, error) {
+				return 42, nil
+			},
+		}))
+
+		assert.Error(t, processor.Process(&p))
+	})
+}
+
+func TestOrderedKeys(t *testing.T) {
+	params := &struct {
+		C string `mock:"c"`
+		A string `mock:"a"`
+		B string `mock:"b"`
+		D string `mock:"c"`
+	}{}
+
+	mock := &mockSource{tagKey: "mock"}
+	assert.NoError(t, Process(params, mock))
+
+	input := mock.processInput
+	input["unordered-b"] = []Parameter{&mockParameter{}}
+	input["unordered-a"] = []Parameter{&mockParameter{}}
+
+	assert.Equal(t, []string{"c", "a", "b", "unordered-a", "unordered-b"}, OrderedKeys(input))
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// Decoder can be implemented by types that need custom handling when decoding a value from a source
+type Decoder interface {
+	Decode(string) error
+}
+
+var (
+	decoderType           = reflect.TypeOf((*Decoder)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// implementsDecoder returns true if the type implements one of the supported decoding interfaces
+func implementsDecoder(typ reflect.Type) bool {
+	return typ.Implements(decoderType) ||
+		typ.Implements(textUnmarshalerType) ||
+		typ.Implements(binaryUnmarshalerType) ||
+		typ.Implements(jsonUnmarshalerType)
+}
+
+// isDecodable returns true if the type or a pointer to the type implements one of the supported decoding
+// interfaces
+func isDecodable(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Interface {
+		return false
+	}
+
+	return implementsDecoder(typ) || implementsDecoder(reflect.PtrTo(typ))
+}
+
+// decoderSetter returns a setter for types that implement Decoder, encoding.TextUnmarshaler,
+// encoding.BinaryUnmarshaler or json.Unmarshaler, in that order of priority
+func decoderSetter(f reflect.Value, typ reflect.Type) setter {
+	return func(s string) error {
+		// pointer types implementing the interface are allocated and decoded into directly, any other type is
+		// decoded via a pointer to a new value
+		if typ.Kind() == reflect.Ptr && implementsDecoder(typ) {
+			val := reflect.New(typ.Elem())
+			if err := decode(val.Interface(), s); err != nil {
+				return err
+			}
+
+			f.Set(val)
+			return nil
+		}
+
+		val := reflect.New(typ)
+		if err := decode(val.Interface(), s); err != nil {
+			return err
+		}
+
+		f.Set(val.Elem())
+		return nil
+	}
+}
+
+func decode(v interface{}, s string) error {
+	switch d := v.(type) {
+	case Decoder:
+		return d.Decode(s)
+	case encoding.TextUnmarshaler:
+		return d.UnmarshalText([]byte(s))
+	case encoding.BinaryUnmarshaler:
+		return d.UnmarshalBinary([]byte(s))
+	case json.Unmarshaler:
+		return decodeJSON(d, s)
+	default:
+		return ErrUnsupportedType
+	}
+}
+
+// decodeJSON passes the value to the unmarshaler as a json string, so that string based types such as enums or ids
+// accept values that look like other json literals, i.e. 123, true or null. If that fails and the value is valid
+// json, i.e. an object or a number for a numeric type, the value is reset and passed as raw json instead
+func decodeJSON(d json.Unmarshaler, s string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	err = d.UnmarshalJSON(b)
+	if err == nil || !json.Valid([]byte(s)) {
+		return err
+	}
+
+	val := reflect.ValueOf(d).Elem()
+	val.Set(reflect.Zero(val.Type()))
+
+	return d.UnmarshalJSON([]byte(s))
+}
@@ -0,0 +1,379 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// DotEnvSource is a source that loads configuration parameters from .env files, layered under the environment. It
+// uses the same env tag, prefix and casing rules as EnvSource and takes its place, i.e. for local development.
+// Variables set in the environment take precedence over values from the files, unless OverrideEnvironment is set.
+// As with EnvSource, variables that are set but empty are treated as unset.
+//
+// Files support comments, an optional export prefix, single quoted values that are used as is, double quoted
+// values with escape sequences, and ${VAR} or $VAR expansion in double quoted and unquoted values. Variables are
+// expanded using values defined earlier in the files, falling back to the environment
+type DotEnvSource struct {
+	EnvSource
+
+	// Files are the .env files to load, in order. Values in later files override values in earlier ones
+	Files []string
+
+	// IgnoreMissing skips files that do not exist instead of returning an error
+	IgnoreMissing bool
+
+	// OverrideEnvironment gives values from the files precedence over variables set in the environment. Variables
+	// the files do not define are still loaded from the environment
+	OverrideEnvironment bool
+}
+
+// Process processes values from the .env files, returning every error that occurred in the order the fields
+// were declared
+func (d *DotEnvSource) Process(paramMap map[string][]Parameter) error {
+	vars, err := d.load()
+	if err != nil {
+		return err
+	}
+
+	var errs *multierror.Error
+
+	for _, key := range OrderedKeys(paramMap) {
+		val := d.lookup(d.ResolveKey(key), vars)
+
+		for _, param := range paramMap[key] {
+			errs = multierror.Append(errs, param.SetValue(val))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// lookup returns the value of the variable from the environment or the files, whichever takes precedence, falling
+// back to the other if the variable is unset or empty
+func (d *DotEnvSource) lookup(name string, vars map[string]string) string {
+	env := os.Getenv(name)
+	if env != "" && !d.OverrideEnvironment {
+		return env
+	}
+
+	if val := vars[name]; val != "" {
+		return val
+	}
+
+	return env
+}
+
+func (d *DotEnvSource) load() (map[string]string, error) {
+	vars := make(map[string]string)
+
+	for _, file := range d.Files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			if d.IgnoreMissing && os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("error reading dotenv file %s: %w", file, err)
+		}
+
+		if err := parseDotEnv(string(data), vars); err != nil {
+			return nil, fmt.Errorf("error parsing dotenv file %s: %w", file, err)
+		}
+	}
+
+	return vars, nil
+}
+
+// dotEnvParser parses the contents of a single .env file
+type dotEnvParser struct {
+	data string
+	pos  int
+	line int
+
+	vars map[string]string
+}
+
+// parseDotEnv parses the contents of a .env file, adding the variables to vars
+func parseDotEnv(data string, vars map[string]string) error {
+	p := &dotEnvParser{
+		data: data,
+		line: 1,
+		vars: vars,
+	}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil
+		}
+
+		switch p.peek() {
+		case '\n':
+			p.next()
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		key := p.readKey()
+		if key == "export" && p.isSpace() {
+			p.skipSpace()
+			key = p.readKey()
+		}
+
+		if key == "" {
+			return p.errorf("expected a variable name")
+		}
+
+		p.skipSpace()
+		if p.eof() || p.peek() != '=' {
+			return p.errorf("expected = after %s", key)
+		}
+
+		p.next()
+		p.skipSpace()
+
+		val, err := p.readValue()
+		if err != nil {
+			return err
+		}
+
+		vars[key] = val
+	}
+}
+
+func (p *dotEnvParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *dotEnvParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *dotEnvParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+
+	if c == '\n' {
+		p.line++
+	}
+
+	return c
+}
+
+func (p *dotEnvParser) isSpace() bool {
+	return !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r')
+}
+
+func (p *dotEnvParser) skipSpace() {
+	for p.isSpace() {
+		p.next()
+	}
+}
+
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotEnvParser) readKey() string {
+	start := p.pos
+	for !p.eof() && isDotEnvKeyChar(p.peek()) {
+		p.next()
+	}
+
+	return p.data[start:p.pos]
+}
+
+func isDotEnvKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isDotEnvNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// readValue reads the value of a variable, up to and including the end of the line
+func (p *dotEnvParser) readValue() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	var (
+		val string
+		err error
+	)
+
+	switch p.peek() {
+	case '\'':
+		val, err = p.readSingleQuoted()
+	case '"':
+		val, err = p.readDoubleQuoted()
+	default:
+		return p.readUnquoted()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// only whitespace and comments may follow a quoted value
+	p.skipSpace()
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return "", p.errorf("unexpected character %q after quoted value", p.peek())
+	}
+
+	p.skipLine()
+	return val, nil
+}
+
+func (p *dotEnvParser) readSingleQuoted() (string, error) {
+	line := p.line
+	p.next()
+
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		p.next()
+	}
+
+	if p.eof() {
+		return "", fmt.Errorf("line %d: unterminated single quoted value", line)
+	}
+
+	val := p.data[start:p.pos]
+	p.next()
+
+	return val, nil
+}
+
+func (p *dotEnvParser) readDoubleQuoted() (string, error) {
+	line := p.line
+	p.next()
+
+	var sb strings.Builder
+
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("line %d: unterminated double quoted value", line)
+		}
+
+		c := p.next()
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				continue
+			}
+
+			switch e := p.next(); e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		case '$':
+			val, err := p.readExpansion()
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(val)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (p *dotEnvParser) readUnquoted() (string, error) {
+	var sb strings.Builder
+
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		prev := byte(' ')
+		if p.pos > start {
+			prev = p.data[p.pos-1]
+		}
+
+		c := p.next()
+
+		switch {
+		case c == '#' && (prev == ' ' || prev == '\t'):
+			// a # at the start of the value or preceded by whitespace starts a comment
+			p.skipLine()
+			return strings.TrimSpace(sb.String()), nil
+		case c == '$':
+			val, err := p.readExpansion()
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(val)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	p.skipLine()
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// readExpansion reads a variable reference following a $, returning its value. A $ that isn't followed by a
+// variable name is returned as is
+func (p *dotEnvParser) readExpansion() (string, error) {
+	if p.eof() {
+		return "$", nil
+	}
+
+	var name string
+
+	if p.peek() == '{' {
+		line := p.line
+		p.next()
+
+		start := p.pos
+		for !p.eof() && p.peek() != '}' && p.peek() != '\n' {
+			p.next()
+		}
+
+		if p.eof() || p.peek() != '}' {
+			return "", fmt.Errorf("line %d: unterminated variable reference", line)
+		}
+
+		name = p.data[start:p.pos]
+		p.next()
+	} else {
+		start := p.pos
+		for !p.eof() && isDotEnvNameChar(p.peek()) {
+			p.next()
+		}
+
+		name = p.data[start:p.pos]
+		if name == "" {
+			return "$", nil
+		}
+	}
+
+	if val, ok := p.vars[name]; ok {
+		return val, nil
+	}
+
+	return os.Getenv(name), nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("FROM_ENV", "env-value"))
+
+	testCases := []struct {
+		name      string
+		data      string
+		expected  map[string]string
+		expectErr bool
+	}{{
+		name: "Basic",
+		data: "# comment\n\nFOO=bar\nexport BAZ = qux \nEMPTY=\n",
+		expected: map[string]string{
+			"FOO":   "bar",
+			"BAZ":   "qux",
+			"EMPTY": "",
+		},
+	}, {
+		name: "Comments",
+		data: "FOO=bar # comment\nURL=http://example.com/#anchor\nHASH=#notvalue\n",
+		expected: map[string]string{
+			"FOO":  "bar",
+			"URL":  "http://example.com/#anchor",
+			"HASH": "",
+		},
+	}, {
+		name: "Quoted",
+		data: "SINGLE='${FOO} \\n # not a comment'\nDOUBLE=\"line1\\nline2\\t\\\"quoted\\\" \\$FOO\" # comment\nMULTI=\"a\nb\"\n",
+		expected: map[string]string{
+			"SINGLE": "${FOO} \\n # not a comment",
+			"DOUBLE": "line1\nline2\t\"quoted\" $FOO",
+			"MULTI":  "a\nb",
+		},
+	}, {
+		name: "Expansion",
+		data: "FOO=foo\nBAR=${FOO}-bar\nBAZ=\"$BAR/$FROM_ENV\"\nMISSING=${MISSING}x\nDOLLAR=$ 5\n",
+		expected: map[string]string{
+			"FOO":     "foo",
+			"BAR":     "foo-bar",
+			"BAZ":     "foo-bar/env-value",
+			"MISSING": "x",
+			"DOLLAR":  "$ 5",
+		},
+	}, {
+		name:      "MissingEquals",
+		data:      "FOO bar\n",
+		expectErr: true,
+	}, {
+		name:      "UnterminatedQuote",
+		data:      "FOO=\"bar\n",
+		expectErr: true,
+	}, {
+		name:      "UnterminatedExpansion",
+		data:      "FOO=${BAR\n",
+		expectErr: true,
+	}, {
+		name:      "TrailingCharacters",
+		data:      "FOO='bar' baz\n",
+		expectErr: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vars := make(map[string]string)
+			err := parseDotEnv(tc.data, vars)
+
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, vars)
+		})
+	}
+}
+
+func TestDotEnvSource_Process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotenv")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	assert.NoError(t, ioutil.WriteFile(base, []byte("APP_HOST=base\nAPP_PORT=8080\nAPP_NAME=base\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(local, []byte("APP_HOST=local\n"), 0600))
+
+	type params struct {
+		Host string `env:"host"`
+		Port int    `env:"port"`
+		Name string `env:"name" required:"true"`
+	}
+
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("APP_NAME", "environment"))
+	assert.NoError(t, os.Setenv("APP_HOST", ""))
+
+	t.Run("Normal", func(t *testing.T) {
+		var p params
+		err := Process(&p, &DotEnvSource{
+			EnvSource: EnvSource{Prefix: "app_"},
+			Files:     []string{base, local},
+		})
+
+		// empty variables in the environment don't hide values from the files
+		assert.NoError(t, err)
+		assert.Equal(t, params{Host: "local", Port: 8080, Name: "environment"}, p)
+	})
+
+	t.Run("OverrideEnvironment", func(t *testing.T) {
+		var p params
+		err := Process(&p, &DotEnvSource{
+			EnvSource:           EnvSource{Prefix: "app_"},
+			Files:               []string{base, local},
+			OverrideEnvironment: true,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, params{Host: "local", Port: 8080, Name: "base"}, p)
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		var p params
+		src := &DotEnvSource{
+			EnvSource: EnvSource{Prefix: "app_"},
+			Files:     []string{filepath.Join(dir, "missing"), base},
+		}
+
+		assert.Error(t, Process(&p, src))
+
+		src.IgnoreMissing = true
+		assert.NoError(t, Process(&p, src))
+		assert.Equal(t, params{Host: "base", Port: 8080, Name: "environment"}, p)
+
+		// variables the files don't define are loaded from the environment
+		p = params{}
+		src.Files = []string{filepath.Join(dir, "missing")}
+		assert.NoError(t, Process(&p, src))
+		assert.Equal(t, params{Name: "environment"}, p)
+	})
+}
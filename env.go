@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/hashicorp/go-multierror"
 )
 
 // EnvSource is a source that loads configuration parameters from environment variables
@@ -21,7 +23,8 @@ func (e *EnvSource) TagKey() string {
 	return "env"
 }
 
-func (e *EnvSource) getEnvVar(key string) string {
+// ResolveKey returns the name of the environment variable for a tag value, applying the prefix and casing rules
+func (e *EnvSource) ResolveKey(key string) string {
 	if e.Prefix != "" {
 		key = fmt.Sprintf("%s%s", e.Prefix, key)
 	}
@@ -30,20 +33,25 @@ func (e *EnvSource) getEnvVar(key string) string {
 		key = strings.ToUpper(key)
 	}
 
-	return os.Getenv(key)
+	return key
+}
+
+func (e *EnvSource) getEnvVar(key string) string {
+	return os.Getenv(e.ResolveKey(key))
 }
 
-// Process processes values from environment variables
+// Process processes values from environment variables, returning every error that occurred in the order the
+// fields were declared
 func (e *EnvSource) Process(paramMap map[string][]Parameter) error {
-	for key, params := range paramMap {
+	var errs *multierror.Error
+
+	for _, key := range OrderedKeys(paramMap) {
 		val := e.getEnvVar(key)
 
-		for _, param := range params {
-			if err := param.SetValue(val); err != nil {
-				return err
-			}
+		for _, param := range paramMap[key] {
+			errs = multierror.Append(errs, param.SetValue(val))
 		}
 	}
 
-	return nil
+	return errs.ErrorOrNil()
 }
@@ -124,3 +124,27 @@ func TestEnvSource_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvSource_Process_AllErrors(t *testing.T) {
+	os.Clearenv()
+
+	params := &struct {
+		First  string `env:"TEST_FIRST" required:"true"`
+		Second int    `env:"TEST_SECOND" default:"invalid"`
+		Third  string `env:"TEST_THIRD" required:"true"`
+		Fourth string `env:"TEST_FOURTH" required:"true"`
+	}{}
+
+	err := Process(params)
+
+	var processErr *ProcessError
+	if assert.True(t, errors.As(err, &processErr)) {
+		fields := processErr.Fields()
+		paths := make([]string, len(fields))
+		for i, f := range fields {
+			paths[i] = f.Path
+		}
+
+		assert.Equal(t, []string{"First", "Second", "Third", "Fourth"}, paths)
+	}
+}
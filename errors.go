@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+var (
+	// ErrMissingValue occurs when a required field has no value in any source
+	ErrMissingValue = errors.New("a required value is missing")
+
+	// ErrInvalidValue occurs when a value from a source can't be parsed into the type of a field
+	ErrInvalidValue = errors.New("an invalid value was passed in")
+)
+
+// FieldErrorKind describes why processing a field failed
+type FieldErrorKind int
+
+const (
+	// Missing indicates that a required field did not have a value in any source
+	Missing FieldErrorKind = iota + 1
+	// Parse indicates that a value could not be parsed into the type of the field
+	Parse
+	// Unsupported indicates that the type of the field is not supported
+	Unsupported
+)
+
+// String returns the name of the error kind
+func (k FieldErrorKind) String() string {
+	switch k {
+	case Missing:
+		return "missing"
+	case Parse:
+		return "parse"
+	case Unsupported:
+		return "unsupported"
+	default:
+		return fmt.Sprintf("FieldErrorKind(%d)", int(k))
+	}
+}
+
+// FieldError is returned when processing an individual field fails
+type FieldError struct {
+	// Field is the name of the struct field
+	Field string
+	// Path is the path to the field from the root struct, i.e. Database.Host
+	Path string
+	// Source is the tag key of the source the value came from, and Key the tag value used to look it up. Both are
+	// empty for errors that are not related to a specific source, e.g. when setting the default value fails
+	Source, Key string
+
+	Kind FieldErrorKind
+	Err  error
+
+	// Secret is true if the field holds a secret value. The message of the underlying error is left out of the
+	// error message for secret fields, as it may contain the value
+	Secret bool
+}
+
+// Error returns the error message
+func (e *FieldError) Error() string {
+	switch e.Kind {
+	case Missing:
+		return fmt.Sprintf("error: field %s was specified as required, but %v", e.Path, e.Err)
+	case Unsupported:
+		return fmt.Sprintf("error getting set function for field %s: %v", e.Path, e.Err)
+	default:
+		var err interface{} = e.Err
+		if e.Secret {
+			err = ErrInvalidValue
+		}
+
+		if e.Source == "" {
+			return fmt.Sprintf("error setting default value for field %s: %v", e.Path, err)
+		}
+
+		return fmt.Sprintf("error setting value for field %s from key %s in source %s: %v", e.Path, e.Key, e.Source, err)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows matching the kind of error via errors.Is, using ErrMissingValue, ErrInvalidValue or
+// ErrUnsupportedType
+func (e *FieldError) Is(target error) bool {
+	switch target {
+	case ErrMissingValue:
+		return e.Kind == Missing
+	case ErrInvalidValue:
+		return e.Kind == Parse
+	case ErrUnsupportedType:
+		return e.Kind == Unsupported
+	default:
+		return false
+	}
+}
+
+// ProcessError is returned when processing fails, holding every error that occurred
+type ProcessError struct {
+	errs []error
+}
+
+// append adds errors, flattening any nested ProcessError or multierror
+func (e *ProcessError) append(errs ...error) {
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+			continue
+		case *ProcessError:
+			e.append(err.errs...)
+		case *multierror.Error:
+			e.append(err.Errors...)
+		default:
+			e.errs = append(e.errs, err)
+		}
+	}
+}
+
+// errorOrNil returns the error if any errors occurred, or nil otherwise
+func (e *ProcessError) errorOrNil() error {
+	if e == nil || len(e.errs) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Error returns the error message
+func (e *ProcessError) Error() string {
+	return multierror.ListFormatFunc(e.errs)
+}
+
+// Errors returns every error that occurred
+func (e *ProcessError) Errors() []error {
+	return e.errs
+}
+
+// Fields returns the errors related to individual fields
+func (e *ProcessError) Fields() []*FieldError {
+	var fields []*FieldError
+
+	for _, err := range e.errs {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			fields = append(fields, fieldErr)
+		}
+	}
+
+	return fields
+}
+
+// Unwrap returns every error that occurred, for use with errors.Is and errors.As
+func (e *ProcessError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess_Errors(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_INT", "not-an-int"))
+
+	// fields without a tag are reported before any sources are processed
+	err := Process(&struct {
+		Int    int `env:"TEST_INT"`
+		Nested struct {
+			NoTag string `required:"true"`
+		}
+	}{})
+
+	var processErr *ProcessError
+	if !assert.True(t, errors.As(err, &processErr)) {
+		return
+	}
+
+	assert.True(t, errors.Is(err, ErrMissingValue))
+	assert.False(t, errors.Is(err, ErrInvalidValue))
+
+	fields := processErr.Fields()
+	assert.Len(t, fields, 1)
+	assert.Equal(t, &FieldError{
+		Field: "NoTag",
+		Path:  "Nested.NoTag",
+		Kind:  Missing,
+		Err:   fields[0].Err,
+	}, fields[0])
+
+	err = Process(&struct {
+		Int int `env:"TEST_INT"`
+	}{})
+
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+	assert.False(t, errors.Is(err, ErrMissingValue))
+
+	var numErr *strconv.NumError
+	assert.True(t, errors.As(err, &numErr))
+
+	var fieldErr *FieldError
+	if assert.True(t, errors.As(err, &fieldErr)) {
+		assert.Equal(t, Parse, fieldErr.Kind)
+		assert.Equal(t, "env", fieldErr.Source)
+		assert.Equal(t, "TEST_INT", fieldErr.Key)
+	}
+
+	err = Process(&struct {
+		Required string `env:"TEST_REQUIRED" required:"true"`
+		Default  int    `env:"TEST_DEFAULT" default:"not-an-int"`
+	}{})
+
+	assert.True(t, errors.As(err, &processErr))
+	assert.True(t, errors.Is(err, ErrMissingValue))
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+	assert.False(t, errors.Is(err, ErrUnsupportedType))
+
+	fieldErrs := make(map[string]*FieldError)
+	for _, f := range processErr.Fields() {
+		fieldErrs[f.Field] = f
+	}
+
+	if assert.Len(t, fieldErrs, 2) {
+		assert.Equal(t, Missing, fieldErrs["Required"].Kind)
+		assert.Equal(t, "env", fieldErrs["Required"].Source)
+		assert.Equal(t, "TEST_REQUIRED", fieldErrs["Required"].Key)
+
+		assert.Equal(t, Parse, fieldErrs["Default"].Kind)
+		assert.Empty(t, fieldErrs["Default"].Source)
+		assert.Contains(t, fieldErrs["Default"].Error(), "error setting default value for field Default")
+	}
+}
+
+func TestProcess_UnsupportedError(t *testing.T) {
+	err := Process(&struct {
+		Chan chan int `env:"TEST_CHAN"`
+	}{})
+
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+
+	var fieldErr *FieldError
+	if assert.True(t, errors.As(err, &fieldErr)) {
+		assert.Equal(t, Unsupported, fieldErr.Kind)
+		assert.Equal(t, "Chan", fieldErr.Path)
+	}
+}
+
+func TestFieldErrorKind_String(t *testing.T) {
+	assert.Equal(t, "missing", Missing.String())
+	assert.Equal(t, "parse", Parse.String())
+	assert.Equal(t, "unsupported", Unsupported.String())
+	assert.Equal(t, "FieldErrorKind(0)", FieldErrorKind(0).String())
+}
@@ -0,0 +1,188 @@
+// Package file implements a config source that loads values from structured files, such as json or yaml, where
+// tag values are dotted paths into the document, i.e. database.host or servers.0.name
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/onetwentyseven-dev/go-config"
+)
+
+var (
+	_ config.Source      = new(Source)
+	_ config.KeyResolver = new(Source)
+)
+
+// DecodeFunc decodes the contents of a file into a document made up of maps, slices and scalar values
+type DecodeFunc func([]byte) (interface{}, error)
+
+// Source is a source that loads configuration parameters from a structured file
+type Source struct {
+	// Key is the tag key used for this source
+	Key string
+	// Path is the path to the file
+	Path string
+	// Decode decodes the contents of the file
+	Decode DecodeFunc
+	// IgnoreMissing treats a file that does not exist as an empty document instead of returning an error
+	IgnoreMissing bool
+}
+
+// New creates a new source for the file at path, using the given tag key and decode function
+func New(tagKey, path string, decode DecodeFunc) *Source {
+	return &Source{
+		Key:    tagKey,
+		Path:   path,
+		Decode: decode,
+	}
+}
+
+// TagKey returns the tag key for the file source
+func (s *Source) TagKey() string {
+	return s.Key
+}
+
+// ResolveKey returns the path looked up for a tag value, dropping any options following a comma
+func (s *Source) ResolveKey(tagValue string) string {
+	return strings.TrimSpace(strings.Split(tagValue, ",")[0])
+}
+
+func (s *Source) load() (interface{}, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		if s.IgnoreMissing && os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading file %s: %w", s.Path, err)
+	}
+
+	doc, err := s.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding file %s: %w", s.Path, err)
+	}
+
+	return doc, nil
+}
+
+// Process processes values from the file, loading it once per call
+func (s *Source) Process(paramMap map[string][]config.Parameter) error {
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	var errs *multierror.Error
+
+	for _, key := range config.OrderedKeys(paramMap) {
+		v, _ := Lookup(doc, s.ResolveKey(key))
+		val := Format(v)
+
+		for _, p := range paramMap[key] {
+			// arrays are set element by element where possible, rather than joined and split on a separator
+			if list, ok := v.([]interface{}); ok {
+				if lp, ok := p.(config.ListParameter); ok {
+					errs = multierror.Append(errs, lp.SetList(formatList(list)))
+					continue
+				}
+			}
+
+			errs = multierror.Append(errs, p.SetValue(val))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// Lookup returns the value at the dotted path in the document. Path segments address keys of maps, or indexes
+// of slices
+func Lookup(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return doc, doc != nil
+	}
+
+	cur := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+
+			cur = next
+		case map[interface{}]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, cur != nil
+}
+
+// formatList formats every element of an array from a document
+func formatList(list []interface{}) []string {
+	vals := make([]string, len(list))
+	for i, val := range list {
+		vals[i] = Format(val)
+	}
+
+	return vals
+}
+
+// Format formats a value from a document as a string that can be passed to Parameter.SetValue. Slices are
+// joined with commas and maps are formatted as key:value pairs joined with commas, sorted by key
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []interface{}:
+		return strings.Join(formatList(v), ",")
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, val := range v {
+			pairs = append(pairs, key+":"+Format(val))
+		}
+
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case map[interface{}]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, val := range v {
+			pairs = append(pairs, Format(key)+":"+Format(val))
+		}
+
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,120 @@
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"servers": []interface{}{
+			map[interface{}]interface{}{"name": "a"},
+			map[interface{}]interface{}{"name": "b"},
+		},
+		"empty": nil,
+	}
+
+	testCases := []struct {
+		path     string
+		expected interface{}
+		found    bool
+	}{
+		{path: "database.host", expected: "localhost", found: true},
+		{path: "database.port", expected: 5432, found: true},
+		{path: "servers.1.name", expected: "b", found: true},
+		{path: "servers.2.name"},
+		{path: "servers.name"},
+		{path: "database.host.name"},
+		{path: "missing"},
+		{path: "empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			val, found := Lookup(doc, tc.path)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, val)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "", Format(nil))
+	assert.Equal(t, "str", Format("str"))
+	assert.Equal(t, "42", Format(42))
+	assert.Equal(t, "3.14", Format(3.14))
+	assert.Equal(t, "true", Format(true))
+	assert.Equal(t, "2020-01-02T03:04:05Z", Format(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, "a,1,false", Format([]interface{}{"a", 1, false}))
+	assert.Equal(t, "a:1,b:2", Format(map[string]interface{}{"b": 2, "a": 1}))
+	assert.Equal(t, "1:a,2:b", Format(map[interface{}]interface{}{2: "b", 1: "a"}))
+}
+
+func TestSource_Process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("ignored"), 0600))
+
+	decode := func([]byte) (interface{}, error) {
+		return map[string]interface{}{
+			"host": "localhost",
+			"tags": []interface{}{"a", "b"},
+			"list": []interface{}{"a,b", "c"},
+		}, nil
+	}
+
+	params := &struct {
+		Host     string   `file:"host,omitempty" required:"true"`
+		Tags     []string `file:"tags"`
+		List     []string `file:"list"`
+		Split    []string `file:"list" separator:";"`
+		Joined   string   `file:"list"`
+		Port     int      `file:"port" default:"8080"`
+		Required string   `file:"required" required:"true"`
+	}{}
+
+	err = config.Process(params, New("file", path, decode))
+	assert.True(t, errors.Is(err, config.ErrMissingValue))
+	assert.Equal(t, "localhost", params.Host)
+	assert.Equal(t, []string{"a", "b"}, params.Tags)
+
+	// arrays are set element by element, regardless of any separator in the elements or tag
+	assert.Equal(t, []string{"a,b", "c"}, params.List)
+	assert.Equal(t, []string{"a,b", "c"}, params.Split)
+	assert.Equal(t, "a,b,c", params.Joined)
+	assert.Equal(t, 8080, params.Port)
+
+	portParams := &struct {
+		Port int `file:"port" default:"8080"`
+	}{}
+
+	src := New("file", filepath.Join(dir, "missing"), decode)
+	assert.Error(t, config.Process(portParams, src))
+
+	src.IgnoreMissing = true
+	assert.NoError(t, config.Process(portParams, src))
+	assert.Equal(t, 8080, portParams.Port)
+
+	src = New("file", path, func([]byte) (interface{}, error) {
+		return nil, errors.New("test error")
+	})
+	assert.Error(t, config.Process(&struct {
+		Port int `file:"port"`
+	}{}, src))
+}
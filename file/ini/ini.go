@@ -0,0 +1,116 @@
+// Package ini implements a config source that loads values from ini files
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/onetwentyseven-dev/go-config/file"
+)
+
+// TagKey is the default tag key for ini sources
+const TagKey = "ini"
+
+// New creates a new source that loads values from the ini file at path, where ini tags address keys as
+// section.key, i.e. `ini:"database.host"`. Keys before the first section are addressed by their name alone
+func New(path string) *file.Source {
+	return file.New(TagKey, path, Decode)
+}
+
+// Decode decodes an ini file into a document. Sections are decoded into nested maps, with dots in section names
+// creating further nesting, i.e. [server.http] is addressed as server.http.key. Lines starting with ; or # are
+// comments, as is anything following a ; or # preceded by whitespace in a value. Values may be wrapped in single or
+// double quotes to include comment characters
+func Decode(data []byte) (interface{}, error) {
+	doc := make(map[string]interface{})
+	section := doc
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "" || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", line)
+			}
+
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", line)
+			}
+
+			var err error
+			if section, err = getSection(doc, name); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		default:
+			parts := strings.SplitN(text, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: expected key = value", line)
+			}
+
+			key := strings.TrimSpace(parts[0])
+			if key == "" {
+				return nil, fmt.Errorf("line %d: empty key", line)
+			}
+
+			section[key] = parseValue(parts[1])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// getSection returns the map for a section, creating it and any parent sections if needed
+func getSection(doc map[string]interface{}, name string) (map[string]interface{}, error) {
+	cur := doc
+
+	for _, part := range strings.Split(name, ".") {
+		part = strings.TrimSpace(part)
+
+		next, ok := cur[part]
+		if !ok {
+			next = make(map[string]interface{})
+			cur[part] = next
+		}
+
+		section, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("section %s conflicts with key %s", name, part)
+		}
+
+		cur = section
+	}
+
+	return cur, nil
+}
+
+// parseValue returns the value with any surrounding whitespace, quotes and inline comment removed
+func parseValue(raw string) string {
+	s := strings.TrimSpace(raw)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			rest := strings.TrimSpace(s[end+2:])
+			if rest == "" || rest[0] == ';' || rest[0] == '#' {
+				return s[1 : end+1]
+			}
+		}
+	}
+
+	for i := 1; i < len(raw); i++ {
+		if (raw[i] == ';' || raw[i] == '#') && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			return strings.TrimSpace(raw[:i])
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,105 @@
+package ini
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		expected  map[string]interface{}
+		expectErr bool
+	}{{
+		name: "Normal",
+		data: "; comment\nname = service\n\n[database]\nhost = \"localhost\"\n# comment\nport=5432\n\n[server.http]\nport = '8080'\n",
+		expected: map[string]interface{}{
+			"name": "service",
+			"database": map[string]interface{}{
+				"host": "localhost",
+				"port": "5432",
+			},
+			"server": map[string]interface{}{
+				"http": map[string]interface{}{
+					"port": "8080",
+				},
+			},
+		},
+	}, {
+		name: "InlineComments",
+		data: "timeout = 30 ; seconds\nretries = 3\t# attempts\nurl = http://host/#anchor\nkey=a;b\n" +
+			"quoted = \"a ; b\" ; comment\nsingle = 'c # d'\nempty = ; comment\n",
+		expected: map[string]interface{}{
+			"timeout": "30",
+			"retries": "3",
+			"url":     "http://host/#anchor",
+			"key":     "a;b",
+			"quoted":  "a ; b",
+			"single":  "c # d",
+			"empty":   "",
+		},
+	}, {
+		name:      "UnterminatedSection",
+		data:      "[database\n",
+		expectErr: true,
+	}, {
+		name:      "EmptySection",
+		data:      "[]\n",
+		expectErr: true,
+	}, {
+		name:      "MissingEquals",
+		data:      "[database]\nhost\n",
+		expectErr: true,
+	}, {
+		name:      "EmptyKey",
+		data:      "= value\n",
+		expectErr: true,
+	}, {
+		name:      "SectionConflict",
+		data:      "server = a\n[server.http]\n",
+		expectErr: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Decode([]byte(tc.data))
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, doc)
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ini")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.ini")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("[database]\nhost = localhost\nport = 5432 ; default port\nreplicas = a,b\n"), 0600))
+
+	params := &struct {
+		Host     string   `ini:"database.host"`
+		Port     int      `ini:"database.port"`
+		Replicas []string `ini:"database.replicas"`
+		User     string   `ini:"database.user" default:"admin"`
+	}{}
+
+	assert.NoError(t, config.Process(params, New(path)))
+	assert.Equal(t, "localhost", params.Host)
+	assert.Equal(t, 5432, params.Port)
+	assert.Equal(t, []string{"a", "b"}, params.Replicas)
+	assert.Equal(t, "admin", params.User)
+}
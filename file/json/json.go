@@ -0,0 +1,31 @@
+// Package json implements a config source that loads values from json files
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/onetwentyseven-dev/go-config/file"
+)
+
+// TagKey is the default tag key for json sources
+const TagKey = "json"
+
+// New creates a new source that loads values from the json file at path, using dotted paths in json tags, i.e.
+// `json:"database.host"`
+func New(path string) *file.Source {
+	return file.New(TagKey, path, Decode)
+}
+
+// Decode decodes json into a document, keeping numbers as json.Number so they are formatted as written
+func Decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
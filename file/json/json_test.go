@@ -0,0 +1,52 @@
+package json
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"database": {"host": "localhost", "port": 5432, "id": 12345678901234567890},
+		"features": ["a", "b"],
+		"weights": {"a": 1.5, "b": 2}
+	}`), 0600))
+
+	params := &struct {
+		Host     string             `json:"database.host"`
+		Port     int                `json:"database.port"`
+		ID       uint64             `json:"database.id"`
+		Features []string           `json:"features"`
+		Weights  map[string]float64 `json:"weights"`
+		Timeout  string             `json:"timeout" default:"10s"`
+		Database struct {
+			Host string `json:"database.host"`
+		} `json:"database"`
+	}{}
+
+	assert.NoError(t, config.Process(params, New(path)))
+	assert.Equal(t, "localhost", params.Host)
+	assert.Equal(t, 5432, params.Port)
+	assert.Equal(t, uint64(12345678901234567890), params.ID)
+	assert.Equal(t, []string{"a", "b"}, params.Features)
+	assert.Equal(t, map[string]float64{"a": 1.5, "b": 2}, params.Weights)
+	assert.Equal(t, "10s", params.Timeout)
+	assert.Equal(t, "localhost", params.Database.Host)
+}
+
+func TestDecode_Error(t *testing.T) {
+	_, err := Decode([]byte("{"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+// Package toml implements a config source that loads values from toml files
+package toml
+
+import (
+	"github.com/onetwentyseven-dev/go-config/file"
+	"github.com/pelletier/go-toml"
+)
+
+// TagKey is the default tag key for toml sources
+const TagKey = "toml"
+
+// New creates a new source that loads values from the toml file at path, using dotted paths in toml tags, i.e.
+// `toml:"database.host"`. Nested tables are addressed via dotted keys, and arrays are joined with commas so they
+// can be loaded into slices
+func New(path string) *file.Source {
+	return file.New(TagKey, path, Decode)
+}
+
+// Decode decodes toml into a document
+func Decode(data []byte) (interface{}, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.ToMap(), nil
+}
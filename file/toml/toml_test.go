@@ -0,0 +1,71 @@
+package toml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "toml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.toml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+name = "service"
+
+[database]
+host = "localhost"
+port = 5432
+timeout = "5s"
+
+[database.pool]
+size = 10
+
+[[servers]]
+name = "a"
+
+[[servers]]
+name = "b"
+
+[features]
+enabled = ["a", "b", "c"]
+ports = [80, 443]
+`), 0600))
+
+	params := &struct {
+		Name     string        `toml:"name"`
+		Host     string        `toml:"database.host"`
+		Port     int           `toml:"database.port"`
+		Timeout  time.Duration `toml:"database.timeout"`
+		PoolSize int           `toml:"database.pool.size"`
+		Server   string        `toml:"servers.1.name"`
+		Enabled  []string      `toml:"features.enabled"`
+		Ports    []int         `toml:"features.ports"`
+		Missing  string        `toml:"missing" default:"default"`
+	}{}
+
+	assert.NoError(t, config.Process(params, New(path)))
+	assert.Equal(t, "service", params.Name)
+	assert.Equal(t, "localhost", params.Host)
+	assert.Equal(t, 5432, params.Port)
+	assert.Equal(t, 5*time.Second, params.Timeout)
+	assert.Equal(t, 10, params.PoolSize)
+	assert.Equal(t, "b", params.Server)
+	assert.Equal(t, []string{"a", "b", "c"}, params.Enabled)
+	assert.Equal(t, []int{80, 443}, params.Ports)
+	assert.Equal(t, "default", params.Missing)
+}
+
+func TestDecode_Error(t *testing.T) {
+	_, err := Decode([]byte("key = "))
+	assert.Error(t, err)
+}
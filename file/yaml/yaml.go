@@ -0,0 +1,26 @@
+// Package yaml implements a config source that loads values from yaml files
+package yaml
+
+import (
+	"github.com/onetwentyseven-dev/go-config/file"
+	"gopkg.in/yaml.v3"
+)
+
+// TagKey is the default tag key for yaml sources
+const TagKey = "yaml"
+
+// New creates a new source that loads values from the yaml file at path, using dotted paths in yaml tags, i.e.
+// `yaml:"database.host"`
+func New(path string) *file.Source {
+	return file.New(TagKey, path, Decode)
+}
+
+// Decode decodes yaml into a document
+func Decode(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
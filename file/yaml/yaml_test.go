@@ -0,0 +1,59 @@
+package yaml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+database:
+  host: localhost
+  port: 5432
+  timeout: 5s
+servers:
+  - name: a
+  - name: b
+labels:
+  team: platform
+  env: prod
+started: 2020-01-02T03:04:05Z
+`), 0600))
+
+	params := &struct {
+		Host    string            `yaml:"database.host"`
+		Port    int               `yaml:"database.port"`
+		Timeout time.Duration     `yaml:"database.timeout"`
+		Server  string            `yaml:"servers.1.name"`
+		Labels  map[string]string `yaml:"labels"`
+		Started time.Time         `yaml:"started"`
+		Missing string            `yaml:"missing" default:"default"`
+	}{}
+
+	assert.NoError(t, config.Process(params, New(path)))
+	assert.Equal(t, "localhost", params.Host)
+	assert.Equal(t, 5432, params.Port)
+	assert.Equal(t, 5*time.Second, params.Timeout)
+	assert.Equal(t, "b", params.Server)
+	assert.Equal(t, map[string]string{"team": "platform", "env": "prod"}, params.Labels)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), params.Started)
+	assert.Equal(t, "default", params.Missing)
+}
+
+func TestDecode_Error(t *testing.T) {
+	_, err := Decode([]byte("key: [unterminated"))
+	assert.Error(t, err)
+}
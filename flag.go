@@ -0,0 +1,144 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// FlagSource is a source that loads configuration parameters from command line flags. A flag is registered for
+// every field with a flag tag, using the desc and default tags for the help text. Only flags that are set on the
+// command line provide a value, so flag defaults never override values from other sources
+type FlagSource struct {
+	// FlagSet is the flag set flags are registered on and parsed with. If nil, a new flag set is created for
+	// every call to Process
+	FlagSet *flag.FlagSet
+	// Args are the arguments to parse, if nil os.Args[1:] is used
+	Args []string
+}
+
+// TagKey returns the tag key for the flag source
+func (s *FlagSource) TagKey() string {
+	return "flag"
+}
+
+// flagValue implements flag.Value for a single parameter, recording the value if the flag is set
+type flagValue struct {
+	defaultValue string
+	isBool       bool
+
+	value string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.defaultValue
+}
+
+func (v *flagValue) Set(s string) error {
+	v.value = s
+	return nil
+}
+
+// IsBoolFlag allows boolean flags to be set without a value, i.e. -verbose
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// newFlagValue returns the flag value and help text for the parameters of a flag
+func newFlagValue(params []Parameter) (*flagValue, string) {
+	v := &flagValue{}
+	var usage string
+
+	for _, p := range params {
+		param, ok := p.(*parameter)
+		if !ok {
+			continue
+		}
+
+		f := param.field
+
+		typ := f.typ
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		v.isBool = v.isBool || typ.Kind() == reflect.Bool
+
+		if usage == "" {
+			usage = f.description
+		}
+
+		if v.defaultValue == "" {
+			v.defaultValue = f.defaultValue
+			if f.secret && v.defaultValue != "" {
+				v.defaultValue = redacted
+			}
+		}
+	}
+
+	return v, usage
+}
+
+// Process registers a flag for every parameter, parses the arguments and sets the values of any flags that
+// were set
+func (s *FlagSource) Process(paramMap map[string][]Parameter) error {
+	fs := s.FlagSet
+	if fs == nil {
+		fs = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	}
+
+	args := s.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+
+	keys := OrderedKeys(paramMap)
+	values := make(map[string]*flagValue, len(keys))
+
+	for _, key := range keys {
+		// flags may already be registered by an earlier call to Process with the same flag set
+		if existing := fs.Lookup(key); existing != nil {
+			v, ok := existing.Value.(*flagValue)
+			if !ok {
+				return fmt.Errorf("error: flag %s is already defined on the flag set", key)
+			}
+
+			values[key] = v
+			continue
+		}
+
+		v, usage := newFlagValue(paramMap[key])
+		fs.Var(v, key, usage)
+		values[key] = v
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var errs *multierror.Error
+
+	for _, key := range keys {
+		for _, p := range paramMap[key] {
+			if set[key] {
+				errs = multierror.Append(errs, p.SetValue(values[key].value))
+			} else {
+				errs = multierror.Append(errs, p.NoValue())
+			}
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
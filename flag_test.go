@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagSource_TagKey(t *testing.T) {
+	src := &FlagSource{}
+
+	assert.Equal(t, "flag", src.TagKey())
+}
+
+func TestFlagSource_Process(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		params    map[string]*mockParameter
+		expectErr bool
+	}{{
+		name: "Normal",
+		args: []string{"-testing-var", "test"},
+		params: map[string]*mockParameter{
+			"testing-var": {
+				expectValue: true,
+			},
+			"other-var": {
+				expectValue: false,
+			},
+		},
+	}, {
+		name: "SetValueError",
+		args: []string{"-testing-var=test"},
+		params: map[string]*mockParameter{
+			"testing-var": {
+				setValErr:   errors.New("test error"),
+				expectValue: true,
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "NoValueError",
+		args: []string{},
+		params: map[string]*mockParameter{
+			"testing-var": {
+				noValErr:    errors.New("test error"),
+				expectValue: false,
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			src := &FlagSource{FlagSet: fs, Args: tc.args}
+
+			paramMap := make(map[string][]Parameter)
+			for k, v := range tc.params {
+				paramMap[k] = []Parameter{v}
+			}
+
+			err := src.Process(paramMap)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, p := range tc.params {
+				p.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestFlagSource_Precedence(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("HOST", "env-host"))
+	assert.NoError(t, os.Setenv("PORT", "9000"))
+
+	var cfg struct {
+		Host    string `flag:"host" env:"HOST" default:"localhost"`
+		Port    int    `flag:"port" env:"PORT" default:"8080"`
+		Verbose bool   `flag:"verbose"`
+		Name    string `flag:"name" default:"default-name"`
+	}
+
+	src := &FlagSource{
+		FlagSet: flag.NewFlagSet("test", flag.ContinueOnError),
+		Args:    []string{"-verbose", "-port", "80", "remaining"},
+	}
+
+	assert.NoError(t, Process(&cfg, src))
+	assert.Equal(t, "env-host", cfg.Host)
+	assert.Equal(t, 80, cfg.Port)
+	assert.True(t, cfg.Verbose)
+	assert.Equal(t, "default-name", cfg.Name)
+	assert.Equal(t, []string{"remaining"}, src.FlagSet.Args())
+}
+
+func TestFlagSource_Usage(t *testing.T) {
+	var cfg struct {
+		Port     int    `flag:"port" default:"8080" desc:"port to listen on"`
+		Password Secret `flag:"password" default:"hunter2" desc:"database password"`
+	}
+
+	var buf bytes.Buffer
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&buf)
+
+	err := Process(&cfg, &FlagSource{FlagSet: fs, Args: []string{"-h"}})
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+
+	out := buf.String()
+	assert.Contains(t, out, "port to listen on (default 8080)")
+	assert.Contains(t, out, "database password (default ******)")
+	assert.NotContains(t, out, "hunter2")
+}
+
+func TestFlagSource_Reuse(t *testing.T) {
+	var cfg struct {
+		Port int `flag:"port"`
+	}
+
+	src := &FlagSource{
+		FlagSet: flag.NewFlagSet("test", flag.ContinueOnError),
+		Args:    []string{"-port", "80"},
+	}
+
+	assert.NoError(t, Process(&cfg, src))
+	cfg.Port = 0
+	assert.NoError(t, Process(&cfg, src))
+	assert.Equal(t, 80, cfg.Port)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	assert.Error(t, Process(&cfg, &FlagSource{FlagSet: fs, Args: []string{}}))
+}
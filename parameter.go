@@ -3,13 +3,17 @@ package config
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
 	defaultTag  = "default"
 	requiredTag = "required"
 	ignoreTag   = "ignore"
+	descTag     = "desc"
+	secretTag   = "secret"
 )
 
 // Parameter represents an individual parameter, used for handling by remote sources
@@ -18,60 +22,366 @@ type Parameter interface {
 	SetValue(string) error
 }
 
-type parameter struct {
-	fieldName        string
-	tagKey, tagValue string
+// ValuesParameter is implemented by parameters that can be set from a set of named values, allowing hierarchical
+// sources to populate map and struct fields from every value beneath a path. Map fields are set from every name
+// and value, struct fields are set by matching names to their fields, where names containing a / address the
+// fields of nested structs
+type ValuesParameter interface {
+	Parameter
+	SetValues(map[string]string) error
+}
+
+// ListParameter is implemented by parameters that can be set from a list of values, allowing sources with native
+// list types to set slice fields without joining and splitting values on a separator. Fields that are not slices
+// are set from the values joined with commas
+type ListParameter interface {
+	Parameter
+	SetList([]string) error
+}
+
+// SecretParameter is implemented by parameters that can be marked as secret by a source that knows a value is
+// sensitive, so that the value is redacted in errors and reports. Parameters must be marked before a value is set
+type SecretParameter interface {
+	Parameter
+	MarkSecret()
+}
+
+// ErrorParameter is implemented by parameters that can be rejected by a source, i.e. when a value is found but fails
+// validation in the source. The error is recorded as a parse error on the field, and the field is resolved so that
+// no default or missing value error follows
+type ErrorParameter interface {
+	Parameter
+	SetError(error) error
+}
+
+// TypedParameter is implemented by parameters that expose the type of the field they set, allowing sources to
+// validate values against the field
+type TypedParameter interface {
+	Parameter
+	Type() reflect.Type
+}
+
+// field represents a single struct field, which may be looked up in multiple sources. The parameters for
+// each source are stored in precedence order, and the first source to provide a value wins
+type field struct {
+	name, path string
+
+	// index is the position of the field in the order fields were declared in the struct
+	index int
+
+	typ         reflect.Type
+	description string
 
 	required     bool
+	secret       bool
 	defaultValue string
 	setFn        setter
+
+	// valuesFn sets the field from a set of named values, nil if the field can not be set that way
+	valuesFn valuesSetter
+
+	// listFn sets the field from a list of values, nil if the field is not a slice
+	listFn listSetter
+
+	// onSet is called after a value has been set, used to allocate any parent struct pointers
+	onSet func()
+
+	params []*parameter
+
+	// hasValue is true once a source has provided a value for the field, done is true once the field has
+	// been resolved, either by a value, a default or a missing value error
+	hasValue, done bool
+
+	// the following record where the value of the field came from, source is nil if the value was not set
+	// by a source
+	source      *parameter
+	value       string
+	usedDefault bool
+	err         error
 }
 
-func newParameter(sf reflect.StructField, setFn setter, tagKey, tagValue string) Parameter {
+func newField(sf reflect.StructField, path string, index int, setFn setter, onSet func()) *field {
 	// ignore error parsing required tag, if it's not valid we just assume not required
 	required, _ := strconv.ParseBool(sf.Tag.Get(requiredTag))
+	secret, _ := strconv.ParseBool(sf.Tag.Get(secretTag))
+	secret = secret || isSecretType(sf.Type)
 
-	return &parameter{
-		fieldName:    sf.Name,
-		tagKey:       tagKey,
-		tagValue:     tagValue,
+	return &field{
+		name:         sf.Name,
+		path:         path,
+		index:        index,
+		typ:          sf.Type,
+		description:  sf.Tag.Get(descTag),
 		required:     required,
+		secret:       secret,
 		defaultValue: sf.Tag.Get(defaultTag),
 		setFn:        setFn,
+		onSet:        onSet,
 	}
 }
 
-// NoValue handles the case where a value is not found in a source
-func (p parameter) NoValue() error {
-	if p.required && p.defaultValue == "" {
-		return fmt.Errorf(
-			"error: field %s was specified as required, but was not found via key %s in source %s",
-			p.fieldName,
-			p.tagValue,
-			p.tagKey,
-		)
+func (f *field) newParameter(tagKey, tagValue string) Parameter {
+	p := &parameter{
+		field:    f,
+		tagKey:   tagKey,
+		tagValue: tagValue,
 	}
 
-	if p.defaultValue != "" {
-		return p.set(p.defaultValue)
+	f.params = append(f.params, p)
+	return p
+}
+
+// noValue handles the case where no source provided a value for the field
+func (f *field) noValue() error {
+	f.done = true
+
+	if f.required && f.defaultValue == "" {
+		keys := make([]string, len(f.params))
+		for i, p := range f.params {
+			keys[i] = fmt.Sprintf("key %s in source %s", p.tagValue, p.tagKey)
+		}
+
+		f.err = &FieldError{
+			Field:  f.name,
+			Path:   f.path,
+			Source: f.params[0].tagKey,
+			Key:    f.params[0].tagValue,
+			Kind:   Missing,
+			Err:    fmt.Errorf("was not found via %s", strings.Join(keys, ", ")),
+		}
+
+		return f.err
+	}
+
+	if f.defaultValue != "" {
+		return f.set(f.defaultValue, nil)
 	}
 
 	return nil
 }
 
-// SetValue sets a value in the parameter using the value from a source
+// set sets the value of the field, p is the parameter the value came from, or nil for the default value
+func (f *field) set(val string, p *parameter) error {
+	return f.apply(val, p, func() error {
+		return f.setFn(val)
+	})
+}
+
+// setValues sets the value of the field from a set of named values provided by p
+func (f *field) setValues(values map[string]string, p *parameter) error {
+	return f.apply(formatValues(values), p, func() error {
+		if f.valuesFn == nil {
+			return fmt.Errorf("%w: %s can not be set from a set of named values", ErrUnsupportedType, f.typ)
+		}
+
+		return f.valuesFn(values, p.tagKey)
+	})
+}
+
+// setList sets the value of the field from a list of values provided by p. Fields that are not slices are set
+// from the values joined with commas
+func (f *field) setList(values []string, p *parameter) error {
+	val := strings.Join(values, ",")
+
+	return f.apply(val, p, func() error {
+		if f.listFn == nil {
+			return f.setFn(val)
+		}
+
+		return f.listFn(values)
+	})
+}
+
+// apply sets the value of the field using fn, recording val as the value and p as where it came from
+func (f *field) apply(val string, p *parameter, fn func() error) error {
+	f.source = p
+	f.value = val
+	f.usedDefault = p == nil
+
+	if err := fn(); err != nil {
+		fieldErr := &FieldError{
+			Field:  f.name,
+			Path:   f.path,
+			Kind:   Parse,
+			Err:    err,
+			Secret: f.secret,
+		}
+
+		if p != nil {
+			fieldErr.Source = p.tagKey
+			fieldErr.Key = p.tagValue
+		}
+
+		f.err = fieldErr
+		return fieldErr
+	}
+
+	if f.onSet != nil {
+		f.onSet()
+	}
+
+	return nil
+}
+
+type parameter struct {
+	field            *field
+	tagKey, tagValue string
+}
+
+func (p *parameter) order() int {
+	return p.field.index
+}
+
+// isLast returns true if there are no lower precedence sources left that could provide a value
+func (p *parameter) isLast() bool {
+	return p.field.params[len(p.field.params)-1] == p
+}
+
+// NoValue handles the case where a value is not found in a source. If a lower precedence source can still
+// provide a value for the field, handling the missing value is deferred to that source
+func (p *parameter) NoValue() error {
+	if p.field.done || !p.isLast() {
+		return nil
+	}
+
+	return p.field.noValue()
+}
+
+// SetValue sets a value in the parameter using the value from a source. If a higher precedence source has
+// already set a value for the field, the value is ignored
 func (p *parameter) SetValue(val string) error {
 	if val == "" {
 		return p.NoValue()
 	}
 
-	return p.set(val)
+	if p.field.hasValue {
+		return nil
+	}
+
+	p.field.hasValue = true
+	p.field.done = true
+
+	return p.field.set(val, p)
 }
 
-func (p *parameter) set(val string) error {
-	if err := p.setFn(val); err != nil {
-		return fmt.Errorf("error setting value for field %s: %w", p.fieldName, err)
+// SetValues sets the value of a map or struct field from a set of named values. If a higher precedence source has
+// already set a value for the field, the values are ignored
+func (p *parameter) SetValues(values map[string]string) error {
+	if len(values) == 0 {
+		return p.NoValue()
 	}
 
-	return nil
+	if p.field.hasValue {
+		return nil
+	}
+
+	p.field.hasValue = true
+	p.field.done = true
+
+	return p.field.setValues(values, p)
+}
+
+// SetList sets the value of the field from a list of values. If a higher precedence source has already set a value
+// for the field, the values are ignored
+func (p *parameter) SetList(values []string) error {
+	if len(values) == 0 {
+		return p.NoValue()
+	}
+
+	if p.field.hasValue {
+		return nil
+	}
+
+	p.field.hasValue = true
+	p.field.done = true
+
+	return p.field.setList(values, p)
+}
+
+// SetError records the error as a parse error on the field, returning the resulting FieldError. If a higher
+// precedence source has already set a value for the field, the error is ignored
+func (p *parameter) SetError(err error) error {
+	if p.field.hasValue {
+		return nil
+	}
+
+	p.field.hasValue = true
+	p.field.done = true
+
+	return p.field.apply("", p, func() error {
+		return err
+	})
+}
+
+// MarkSecret marks the field as secret, so that its value is redacted in errors and reports
+func (p *parameter) MarkSecret() {
+	p.field.secret = true
+}
+
+// Type returns the type of the field
+func (p *parameter) Type() reflect.Type {
+	return p.field.typ
+}
+
+// formatValues formats a set of named values as name:value pairs joined with commas, sorted by name
+func formatValues(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s:%s", name, values[name])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// ordered is implemented by parameters that know the position of their field within the struct
+type ordered interface {
+	order() int
+}
+
+// OrderedKeys returns the keys of a parameter map in the order their fields were declared in the struct, so
+// that sources can process parameters, and report errors, in a deterministic order. Keys without a known order
+// are sorted alphabetically after all others
+func OrderedKeys(paramMap map[string][]Parameter) []string {
+	keys := make([]string, 0, len(paramMap))
+	orders := make(map[string]int, len(paramMap))
+
+	for key, params := range paramMap {
+		keys = append(keys, key)
+		orders[key] = -1
+
+		for _, p := range params {
+			o, ok := p.(ordered)
+			if !ok {
+				continue
+			}
+
+			if orders[key] == -1 || o.order() < orders[key] {
+				orders[key] = o.order()
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		oi, oj := orders[keys[i]], orders[keys[j]]
+
+		switch {
+		case oi == oj:
+			return keys[i] < keys[j]
+		case oi == -1:
+			return false
+		case oj == -1:
+			return true
+		default:
+			return oi < oj
+		}
+	})
+
+	return keys
 }
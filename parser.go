@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ParserFunc parses a value from a source into a value of a specific type. The returned value should either be
+// of the type the parser was registered for, or a pointer to it
+type ParserFunc func(string) (interface{}, error)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[reflect.Type]ParserFunc)
+)
+
+// RegisterParser registers a parser for the given type with every Processor, allowing types that can't
+// implement Decoder to be used as fields. Parsers passed to a Processor via WithParsers take priority over
+// parsers registered globally
+func RegisterParser(typ reflect.Type, fn ParserFunc) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	parsers[typ] = fn
+}
+
+// WithParsers adds parsers for the given types to a Processor, taking priority over globally registered parsers
+func WithParsers(typeParsers map[reflect.Type]ParserFunc) Option {
+	return func(p *Processor) {
+		if p.parsers == nil {
+			p.parsers = make(map[reflect.Type]ParserFunc, len(typeParsers))
+		}
+
+		for typ, fn := range typeParsers {
+			p.parsers[typ] = fn
+		}
+	}
+}
+
+// lookupParser returns the parser for the given type, if any. Parsers passed via WithParsers take priority over
+// globally registered parsers, which take priority over the built in parsers
+func lookupParser(typ reflect.Type, opts setterOptions) (ParserFunc, bool) {
+	if fn, ok := opts.parsers[typ]; ok {
+		return fn, true
+	}
+
+	parsersMu.RLock()
+	fn, ok := parsers[typ]
+	parsersMu.RUnlock()
+
+	if ok {
+		return fn, true
+	}
+
+	if fn, ok := builtinParsers[typ]; ok {
+		return func(s string) (interface{}, error) {
+			return fn(s, opts.tag)
+		}, true
+	}
+
+	return nil, false
+}
+
+// getParserSetter returns a setter for the type if a parser was registered either for the type itself or for a
+// pointer to the type
+func getParserSetter(f reflect.Value, typ reflect.Type, opts setterOptions) (setter, bool) {
+	if fn, ok := lookupParser(typ, opts); ok {
+		return parserSetter(f, typ, fn), true
+	}
+
+	if typ.Kind() != reflect.Ptr {
+		if fn, ok := lookupParser(reflect.PtrTo(typ), opts); ok {
+			return parserSetter(f, typ, fn), true
+		}
+	}
+
+	return nil, false
+}
+
+func parserSetter(f reflect.Value, typ reflect.Type, fn ParserFunc) setter {
+	return func(s string) error {
+		v, err := fn(s)
+		if err != nil {
+			return err
+		}
+
+		val := reflect.ValueOf(v)
+
+		switch {
+		case !val.IsValid():
+			f.Set(reflect.Zero(typ))
+		case val.Type().AssignableTo(typ):
+			f.Set(val)
+		case val.Kind() == reflect.Ptr && val.Type().Elem().AssignableTo(typ):
+			if val.IsNil() {
+				f.Set(reflect.Zero(typ))
+			} else {
+				f.Set(val.Elem())
+			}
+		default:
+			return fmt.Errorf("parser for type %s returned a value of type %s", typ, val.Type())
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// redacted replaces the values of secret fields in any output
+const redacted = "******"
+
+// ReportField records where the value of a single field came from
+type ReportField struct {
+	Field, Path string
+
+	// Source is the tag key of the source that provided the value, and Key the key it was looked up with. Both
+	// are empty if no source provided a value
+	Source, Key string
+
+	// Default is true if the value came from the default tag
+	Default bool
+
+	// Value is the raw value that was set, redacted for secret fields
+	Value string
+
+	// Err is the error that occurred processing the field, if any
+	Err error
+}
+
+// Report records the provenance of every field set by a call to ProcessWithReport
+type Report struct {
+	Fields []ReportField
+}
+
+// String returns the report formatted as a table
+func (r *Report) String() string {
+	var sb strings.Builder
+
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tSOURCE\tKEY\tDEFAULT\tVALUE\tERROR")
+
+	for _, f := range r.Fields {
+		var errMsg string
+		if f.Err != nil {
+			errMsg = f.Err.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n", f.Path, f.Source, f.Key, f.Default, f.Value, errMsg)
+	}
+
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// ProcessWithReport handles processing values from various sources, returning a report of where the value of
+// each field came from
+func ProcessWithReport(params interface{}, sources ...Source) (*Report, error) {
+	return New().ProcessWithReportContext(context.Background(), params, sources...)
+}
+
+// ProcessWithReportContext handles processing values from various sources, stopping early if the context is
+// done, returning a report of where the value of each field came from
+func ProcessWithReportContext(ctx context.Context, params interface{}, sources ...Source) (*Report, error) {
+	return New().ProcessWithReportContext(ctx, params, sources...)
+}
+
+// ProcessWithReport handles processing values from various sources, returning a report of where the value of
+// each field came from
+func (p *Processor) ProcessWithReport(params interface{}, sources ...Source) (*Report, error) {
+	return p.ProcessWithReportContext(context.Background(), params, sources...)
+}
+
+// ProcessWithReportContext handles processing values from various sources, stopping early if the context is
+// done, returning a report of where the value of each field came from. The report is returned even if an
+// error occurs, as long as the sources are valid
+func (p *Processor) ProcessWithReportContext(ctx context.Context, params interface{}, sources ...Source) (*Report, error) {
+	ps, err := p.process(ctx, params, sources)
+	if ps == nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Fields: make([]ReportField, len(ps.fields)),
+	}
+
+	for i, f := range ps.fields {
+		rf := ReportField{
+			Field:   f.name,
+			Path:    f.path,
+			Default: f.usedDefault,
+			Value:   f.value,
+			Err:     f.err,
+		}
+
+		if f.source != nil {
+			rf.Source = f.source.tagKey
+			rf.Key = ps.resolveKey(f.source)
+		}
+
+		if f.secret && rf.Value != "" {
+			rf.Value = redacted
+		}
+
+		report.Fields[i] = rf
+	}
+
+	return report, err
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessWithReport(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("APP_HOST", "localhost"))
+	assert.NoError(t, os.Setenv("APP_PASSWORD", "hunter2"))
+
+	params := &struct {
+		Host     string `env:"host" mock:"host"`
+		Port     int    `env:"port" mock:"port"`
+		Timeout  string `env:"timeout" default:"10s"`
+		Password string `env:"password" secret:"true"`
+		Missing  string `env:"missing" required:"true"`
+		Optional string `env:"optional"`
+	}{}
+
+	mock := &mockSource{
+		tagKey: "mock",
+		vars: map[string]string{
+			"port": "8080",
+		},
+	}
+
+	report, err := ProcessWithReport(params, &EnvSource{Prefix: "app_"}, mock)
+	assert.True(t, errors.Is(err, ErrMissingValue))
+
+	if !assert.NotNil(t, report) || !assert.Len(t, report.Fields, 6) {
+		return
+	}
+
+	assert.Equal(t, ReportField{
+		Field:  "Host",
+		Path:   "Host",
+		Source: "env",
+		Key:    "APP_HOST",
+		Value:  "localhost",
+	}, report.Fields[0])
+
+	assert.Equal(t, ReportField{
+		Field:  "Port",
+		Path:   "Port",
+		Source: "mock",
+		Key:    "port",
+		Value:  "8080",
+	}, report.Fields[1])
+
+	assert.Equal(t, ReportField{
+		Field:   "Timeout",
+		Path:    "Timeout",
+		Default: true,
+		Value:   "10s",
+	}, report.Fields[2])
+
+	assert.Equal(t, ReportField{
+		Field:  "Password",
+		Path:   "Password",
+		Source: "env",
+		Key:    "APP_PASSWORD",
+		Value:  redacted,
+	}, report.Fields[3])
+
+	assert.Equal(t, "Missing", report.Fields[4].Field)
+	assert.True(t, errors.Is(report.Fields[4].Err, ErrMissingValue))
+
+	assert.Equal(t, ReportField{
+		Field: "Optional",
+		Path:  "Optional",
+	}, report.Fields[5])
+
+	out := report.String()
+	assert.Contains(t, out, "APP_HOST")
+	assert.Contains(t, out, "was not found via key missing in source env")
+	assert.NotContains(t, out, "hunter2")
+}
+
+func TestProcessWithReport_InvalidSources(t *testing.T) {
+	report, err := ProcessWithReport(&struct{}{}, &EnvSource{}, &EnvSource{})
+	assert.Error(t, err)
+	assert.Nil(t, report)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// Secret is a string holding a sensitive value, such as a password or api key, that is redacted whenever it is
+// formatted, logged or marshalled. The actual value can be retrieved with Value. Fields of type Secret are
+// treated as if they had the secret:"true" tag
+type Secret string
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// Value returns the actual value of the secret
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns the redacted value
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString returns the redacted value, for use with the %#v verb
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", redacted)
+}
+
+// Format writes the redacted value for every verb
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'q':
+		_, _ = io.WriteString(f, strconv.Quote(redacted))
+	case verb == 'v' && f.Flag('#'):
+		_, _ = io.WriteString(f, s.GoString())
+	default:
+		_, _ = io.WriteString(f, redacted)
+	}
+}
+
+// MarshalJSON returns the redacted value as a json string
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// MarshalText returns the redacted value
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// isSecretType returns true if the type is a Secret, or a pointer, slice or map of secrets
+func isSecretType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return typ.Elem() == secretType
+	default:
+		return typ == secretType
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter2")
+
+	assert.Equal(t, "hunter2", s.Value())
+	assert.Equal(t, redacted, s.String())
+
+	for _, format := range []string{"%s", "%v", "%+v", "%x", "%d", "%10s"} {
+		assert.Equal(t, redacted, fmt.Sprintf(format, s), format)
+	}
+
+	assert.Equal(t, `"******"`, fmt.Sprintf("%q", s))
+	assert.Equal(t, `config.Secret("******")`, fmt.Sprintf("%#v", s))
+	assert.NotContains(t, fmt.Sprintf("%+v", struct{ Password Secret }{s}), "hunter2")
+
+	b, err := json.Marshal(struct{ Password Secret }{s})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Password":"******"}`, string(b))
+}
+
+func TestProcess_Secrets(t *testing.T) {
+	os.Clearenv()
+	assert.NoError(t, os.Setenv("TEST_PASSWORD", "hunter2"))
+	assert.NoError(t, os.Setenv("TEST_PIN", "not-a-pin-hunter2"))
+	assert.NoError(t, os.Setenv("TEST_KEYS", "a:hunter2,b"))
+
+	params := &struct {
+		Password Secret            `env:"TEST_PASSWORD"`
+		Pin      int               `env:"TEST_PIN" secret:"true"`
+		Keys     map[string]string `env:"TEST_KEYS" secret:"true"`
+		Token    Secret            `env:"TEST_TOKEN" default:"default-token"`
+	}{}
+
+	report, err := ProcessWithReport(params)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+	assert.Equal(t, Secret("hunter2"), params.Password)
+	assert.NotContains(t, err.Error(), "hunter2")
+
+	out := report.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "default-token")
+
+	var buf bytes.Buffer
+	assert.NoError(t, Usage(&buf, params))
+	assert.NotContains(t, buf.String(), "default-token")
+}
@@ -0,0 +1,245 @@
+// Package secretsmanager implements a config source that loads values from AWS Secrets Manager. Tag values name a
+// secret, optionally followed by # and a dotted path into a secret stored as json, i.e. `sm:"prod/db#password"`
+package secretsmanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/hashicorp/go-multierror"
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/onetwentyseven-dev/go-config/file"
+	jsonfile "github.com/onetwentyseven-dev/go-config/file/json"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ config.ContextSource = new(Source)
+	_ config.KeyResolver   = new(Source)
+)
+
+// batchSize is the maximum number of secret ids accepted by a single BatchGetSecretValue call
+const batchSize = 20
+
+// notFoundErrorCode is the error code returned for secrets that do not exist
+const notFoundErrorCode = "ResourceNotFoundException"
+
+// SecretStore represents the Secrets Manager Client methods needed by the secretsmanager config source
+type SecretStore interface {
+	BatchGetSecretValue(context.Context, *secretsmanager.BatchGetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
+}
+
+// Source is a source that pulls secrets from AWS Secrets Manager
+type Source struct {
+	Prefix         string
+	SecretsManager SecretStore
+}
+
+// New creates a new source
+func New(prefix string, client SecretStore) *Source {
+	return &Source{
+		Prefix:         prefix,
+		SecretsManager: client,
+	}
+}
+
+// TagKey returns the tag key for the secretsmanager source
+func (s *Source) TagKey() string {
+	return "sm"
+}
+
+// secret holds the value of a secret, decoding it as json the first time a key within it is looked up
+type secret struct {
+	value string
+
+	decoded bool
+	doc     interface{}
+	err     error
+}
+
+// lookup returns the value for the dotted path within the secret, or the whole secret if the path is empty
+func (s *secret) lookup(path string) (string, error) {
+	if path == "" {
+		return s.value, nil
+	}
+
+	if !s.decoded {
+		s.decoded = true
+		s.doc, s.err = jsonfile.Decode([]byte(s.value))
+	}
+
+	if s.err != nil {
+		return "", errors.Wrap(s.err, "error decoding secret as json")
+	}
+
+	v, ok := file.Lookup(s.doc, path)
+	if !ok {
+		return "", nil
+	}
+
+	return file.Format(v), nil
+}
+
+func (s *Source) getSecrets(ctx context.Context, ids []string) (map[string]*secret, map[string]error, error) {
+	secrets := make(map[string]*secret, len(ids))
+	secretErrs := make(map[string]error)
+
+	for i := 0; i < len(ids); i += batchSize {
+		end := i + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var nextToken *string
+
+		for {
+			// check for cancellation between requests so a slow or large fetch can be aborted
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+
+			response, err := s.SecretsManager.BatchGetSecretValue(ctx, &secretsmanager.BatchGetSecretValueInput{
+				SecretIdList: ids[i:end],
+				NextToken:    nextToken,
+			})
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "error fetching secrets from secrets manager")
+			}
+
+			for _, v := range response.SecretValues {
+				val := &secret{value: aws.ToString(v.SecretString)}
+				if v.SecretString == nil {
+					val.value = string(v.SecretBinary)
+				}
+
+				// secrets may be requested by name or by arn
+				if v.Name != nil {
+					secrets[*v.Name] = val
+				}
+				if v.ARN != nil {
+					secrets[*v.ARN] = val
+				}
+			}
+
+			for _, e := range response.Errors {
+				if e.SecretId == nil || aws.ToString(e.ErrorCode) == notFoundErrorCode {
+					continue
+				}
+
+				secretErrs[*e.SecretId] = fmt.Errorf("%s: %s", aws.ToString(e.ErrorCode), aws.ToString(e.Message))
+			}
+
+			nextToken = response.NextToken
+			if nextToken == nil || *nextToken == "" {
+				break
+			}
+		}
+	}
+
+	return secrets, secretErrs, nil
+}
+
+// Process handles processing of secretsmanager configuration parameters
+func (s *Source) Process(paramMap map[string][]config.Parameter) error {
+	return s.ProcessContext(context.Background(), paramMap)
+}
+
+// ProcessContext handles processing of secretsmanager configuration parameters, aborting if the context is done.
+// Every secret is fetched once, no matter how many fields reference it, and fields set from a secret are marked
+// as secret
+func (s *Source) ProcessContext(ctx context.Context, paramMap map[string][]config.Parameter) error {
+	keys := config.OrderedKeys(paramMap)
+	ids := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		id, _ := getSecretID(key, s.Prefix)
+
+		if !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+
+	secrets, secretErrs, err := s.getSecrets(ctx, ids)
+	if err != nil {
+		return errors.Wrap(err, "error getting secrets")
+	}
+
+	var errs *multierror.Error
+
+	for _, key := range keys {
+		id, path := getSecretID(key, s.Prefix)
+
+		if err, ok := secretErrs[id]; ok {
+			errs = multierror.Append(errs, errors.Wrapf(err, "error fetching secret %s", id))
+			continue
+		}
+
+		var val string
+		if sec, ok := secrets[id]; ok {
+			val, err = sec.lookup(path)
+			if err != nil {
+				errs = multierror.Append(errs, errors.Wrapf(err, "error reading secret %s", id))
+				continue
+			}
+		}
+
+		for _, p := range paramMap[key] {
+			// every value from secrets manager is sensitive, so fields are redacted in errors and reports
+			if sp, ok := p.(config.SecretParameter); ok && val != "" {
+				sp.MarkSecret()
+			}
+
+			errs = multierror.Append(errs, p.SetValue(val))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// ResolveKey returns the secret id and json path for a tag value, applying the prefix and absolute option
+func (s *Source) ResolveKey(tagValue string) string {
+	id, path := getSecretID(tagValue, s.Prefix)
+	if path == "" {
+		return id
+	}
+
+	return fmt.Sprintf("%s#%s", id, path)
+}
+
+// getSecretID returns the id of the secret and the path within it for a tag value
+func getSecretID(tagValue, prefix string) (string, string) {
+	parts := strings.Split(strings.TrimSpace(tagValue), ",")
+
+	var absolute bool
+	if len(parts) > 1 {
+		absolute = contains(parts[1:], "absolute")
+	}
+
+	id := parts[0]
+
+	var path string
+	if i := strings.Index(id, "#"); i >= 0 {
+		id, path = id[:i], id[i+1:]
+	}
+
+	if absolute || strings.HasPrefix(id, prefix) {
+		return id, path
+	}
+
+	return fmt.Sprintf("%s%s", prefix, id), path
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, v := range haystack {
+		if strings.TrimSpace(v) == needle {
+			return true
+		}
+	}
+
+	return false
+}
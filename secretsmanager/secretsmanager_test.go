@@ -0,0 +1,319 @@
+package secretsmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockParameter struct {
+	val string
+	err error
+
+	expectVal bool
+}
+
+func (m *mockParameter) NoValue() error {
+	return nil
+}
+
+func (m *mockParameter) SetValue(val string) error {
+	m.val = val
+	return m.err
+}
+
+func (m *mockParameter) AssertExpectations(t *testing.T) {
+	if m.expectVal {
+		assert.NotEmpty(t, m.val)
+	} else {
+		assert.Empty(t, m.val)
+	}
+}
+
+type mockSecretsManager struct {
+	secrets map[string]string
+	errs    map[string]string
+	err     error
+
+	// pageSize limits the number of secrets returned per call, returning a next token for the rest
+	pageSize int
+
+	calls     int
+	requested []string
+}
+
+func (m *mockSecretsManager) BatchGetSecretValue(_ context.Context, in *secretsmanager.BatchGetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	m.calls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	ids := in.SecretIdList
+	if in.NextToken != nil {
+		for i, id := range ids {
+			if id == *in.NextToken {
+				ids = ids[i:]
+				break
+			}
+		}
+	}
+
+	out := secretsmanager.BatchGetSecretValueOutput{}
+	for i, id := range ids {
+		if m.pageSize > 0 && i == m.pageSize {
+			out.NextToken = aws.String(id)
+			break
+		}
+
+		m.requested = append(m.requested, id)
+
+		if code, ok := m.errs[id]; ok {
+			out.Errors = append(out.Errors, types.APIErrorType{
+				SecretId:  aws.String(id),
+				ErrorCode: aws.String(code),
+				Message:   aws.String("test error"),
+			})
+			continue
+		}
+
+		s, ok := m.secrets[id]
+		if !ok {
+			out.Errors = append(out.Errors, types.APIErrorType{
+				SecretId:  aws.String(id),
+				ErrorCode: aws.String(notFoundErrorCode),
+				Message:   aws.String("not found"),
+			})
+			continue
+		}
+
+		out.SecretValues = append(out.SecretValues, types.SecretValueEntry{
+			ARN:          aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:" + id),
+			Name:         aws.String(id),
+			SecretString: aws.String(s),
+		})
+	}
+
+	return &out, nil
+}
+
+func TestSource_TagKey(t *testing.T) {
+	var src Source
+	assert.Equal(t, "sm", src.TagKey())
+}
+
+func TestSource_Process(t *testing.T) {
+	testCases := []struct {
+		name string
+
+		params map[string][]*mockParameter
+		mock   mockSecretsManager
+		prefix string
+
+		expectErr bool
+	}{{
+		name: "ErrBatchGetSecretValue",
+		params: map[string][]*mockParameter{
+			"key": {{
+				expectVal: false,
+			}},
+		},
+		mock: mockSecretsManager{
+			err: errors.New("test error"),
+		},
+		expectErr: true,
+	}, {
+		name: "ErrSetParameter",
+		params: map[string][]*mockParameter{
+			"db#password": {{
+				err:       errors.New("test error"),
+				expectVal: true,
+			}},
+		},
+		prefix: "prod/",
+		mock: mockSecretsManager{
+			secrets: map[string]string{
+				"prod/db": `{"password": "secret"}`,
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "ErrSecret",
+		params: map[string][]*mockParameter{
+			"db#password": {{
+				expectVal: false,
+			}},
+			"api-key": {{
+				expectVal: true,
+			}},
+		},
+		prefix: "prod/",
+		mock: mockSecretsManager{
+			secrets: map[string]string{
+				"prod/api-key": "key",
+			},
+			errs: map[string]string{
+				"prod/db": "DecryptionFailure",
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "ErrNotJSON",
+		params: map[string][]*mockParameter{
+			"db#password": {{
+				expectVal: false,
+			}},
+		},
+		prefix: "prod/",
+		mock: mockSecretsManager{
+			secrets: map[string]string{
+				"prod/db": "not json",
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "NormalGet",
+		params: map[string][]*mockParameter{
+			"db#password": {{
+				expectVal: true,
+			}},
+			"db#port": {{
+				expectVal: true,
+			}},
+			"db#replicas.0.host": {{
+				expectVal: true,
+			}},
+			"db#missing": {{
+				expectVal: false,
+			}},
+			"prod/api-key": {{
+				expectVal: true,
+			}},
+			"missing": {{
+				expectVal: false,
+			}},
+			"shared/token,absolute": {{
+				expectVal: true,
+			}},
+		},
+		prefix: "prod/",
+		mock: mockSecretsManager{
+			secrets: map[string]string{
+				"prod/db":      `{"password": "secret", "port": 5432, "replicas": [{"host": "replica-1"}]}`,
+				"prod/api-key": "key",
+				"shared/token": "token",
+			},
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			paramMap := make(map[string][]config.Parameter, len(tc.params))
+			for k, ps := range tc.params {
+				params := make([]config.Parameter, len(ps))
+				for i, p := range ps {
+					params[i] = p
+				}
+
+				paramMap[k] = params
+			}
+
+			source := New(tc.prefix, &tc.mock)
+			err := source.Process(paramMap)
+
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, ps := range tc.params {
+				for _, p := range ps {
+					p.AssertExpectations(t)
+				}
+			}
+		})
+	}
+}
+
+func TestSource_ProcessFetchesOnce(t *testing.T) {
+	var cfg struct {
+		Username string `sm:"db#username"`
+		Password string `sm:"db#password"`
+		Database struct {
+			Host string `sm:"db#host"`
+		}
+		APIKey string `sm:"api-key"`
+	}
+
+	mock := &mockSecretsManager{
+		secrets: map[string]string{
+			"prod/db":      `{"username": "user", "password": "secret", "host": "db.local"}`,
+			"prod/api-key": "key",
+		},
+		pageSize: 1,
+	}
+
+	assert.NoError(t, config.Process(&cfg, New("prod/", mock)))
+	assert.Equal(t, "user", cfg.Username)
+	assert.Equal(t, "secret", cfg.Password)
+	assert.Equal(t, "db.local", cfg.Database.Host)
+	assert.Equal(t, "key", cfg.APIKey)
+
+	// every secret is requested once, with the second page fetched using the next token
+	assert.Equal(t, []string{"prod/db", "prod/api-key"}, mock.requested)
+	assert.Equal(t, 2, mock.calls)
+}
+
+func TestSource_ProcessSecret(t *testing.T) {
+	var cfg struct {
+		Password string `sm:"db#password"`
+		Port     int    `sm:"db#port"`
+		Name     string `env:"TEST_SM_NAME" default:"service"`
+	}
+
+	mock := &mockSecretsManager{
+		secrets: map[string]string{
+			"prod/db": `{"password": "hunter2", "port": "not-a-port"}`,
+		},
+	}
+
+	report, err := config.ProcessWithReport(&cfg, New("prod/", mock))
+	assert.True(t, errors.Is(err, config.ErrInvalidValue))
+	assert.NotContains(t, err.Error(), "not-a-port")
+	assert.Equal(t, "hunter2", cfg.Password)
+
+	if assert.Len(t, report.Fields, 3) {
+		assert.Equal(t, "******", report.Fields[0].Value)
+		assert.Equal(t, "service", report.Fields[2].Value)
+	}
+	assert.NotContains(t, report.String(), "hunter2")
+}
+
+func TestSource_ProcessContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mock := &mockSecretsManager{}
+	source := New("prod/", mock)
+
+	err := source.ProcessContext(ctx, map[string][]config.Parameter{
+		"db#password": {&mockParameter{}},
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, mock.calls)
+}
+
+func TestSource_ResolveKey(t *testing.T) {
+	source := New("prod/", nil)
+
+	assert.Equal(t, "prod/db#password", source.ResolveKey("db#password"))
+	assert.Equal(t, "prod/db", source.ResolveKey("prod/db"))
+	assert.Equal(t, "shared/Token", source.ResolveKey("shared/Token,absolute"))
+}
@@ -14,11 +14,60 @@ var (
 	ErrUnsupportedType = errors.New("an unsupported type configuration was passed in")
 )
 
+const (
+	separatorTag   = "separator"
+	kvSeparatorTag = "kvseparator"
+
+	defaultSeparator   = ","
+	defaultKVSeparator = ":"
+)
+
 type setter func(string) error
 
-func getSetter(f reflect.Value) (setter, error) {
+// setterOptions holds the options used to build a setter for a field
+type setterOptions struct {
+	// tag is the struct tag of the field, used for any type specific options such as the separators used for
+	// slices and maps
+	tag reflect.StructTag
+
+	// parsers holds the parsers registered with the Processor
+	parsers map[reflect.Type]ParserFunc
+}
+
+// hasCustomSetter returns true if values of the type are set via a registered parser or a decoding interface,
+// rather than based on the kind of the type
+func (o setterOptions) hasCustomSetter(typ reflect.Type) bool {
+	if _, ok := lookupParser(typ, o); ok {
+		return true
+	}
+
+	if _, ok := lookupParser(reflect.PtrTo(typ), o); ok {
+		return true
+	}
+
+	return isDecodable(typ)
+}
+
+func getSetter(f reflect.Value, opts setterOptions) (setter, error) {
 	typ := f.Type()
 
+	// registered parsers and types that know how to decode themselves take priority over the kind of the type
+	if fn, ok := getParserSetter(f, typ, opts); ok {
+		return fn, nil
+	}
+
+	// pointers are set via the parser of their element type if it has one, so that options such as the layout of
+	// a *time.Time are honoured rather than decoding via an interface the pointer implements
+	if typ.Kind() == reflect.Ptr {
+		if _, ok := lookupParser(typ.Elem(), opts); ok {
+			return ptrSetter(f, typ, opts)
+		}
+	}
+
+	if isDecodable(typ) {
+		return decoderSetter(f, typ), nil
+	}
+
 	switch typ.Kind() {
 	case reflect.String:
 		return stringSetter(f), nil
@@ -31,9 +80,13 @@ func getSetter(f reflect.Value) (setter, error) {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return uintSetter(f, typ), nil
 	case reflect.Slice:
-		return sliceSetter(f, typ), nil
+		return sliceSetter(f, typ, opts), nil
+	case reflect.Map:
+		return mapSetter(f, typ, opts)
+	case reflect.Ptr:
+		return ptrSetter(f, typ, opts)
 	default:
-		return nil, fmt.Errorf("unsupported type configuration %T was passed in", typ.Kind().String())
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, typ)
 	}
 }
 
@@ -104,17 +157,17 @@ func floatSetter(f reflect.Value, typ reflect.Type) setter {
 	}
 }
 
-func sliceSetter(f reflect.Value, typ reflect.Type) setter {
+func sliceSetter(f reflect.Value, typ reflect.Type, opts setterOptions) setter {
 	return func(s string) error {
 		sl := reflect.MakeSlice(typ, 0, 0)
 
 		if typ.Elem().Kind() == reflect.Uint8 {
 			sl = reflect.ValueOf([]byte(s))
 		} else if len(strings.TrimSpace(s)) > 0 {
-			vals := strings.Split(s, ",")
+			vals := strings.Split(s, getTag(opts.tag, separatorTag, defaultSeparator))
 			sl = reflect.MakeSlice(typ, len(vals), len(vals))
 			for i, val := range vals {
-				fs, err := getSetter(sl.Index(i))
+				fs, err := getSetter(sl.Index(i), opts)
 				if err != nil {
 					return err
 				}
@@ -129,3 +182,256 @@ func sliceSetter(f reflect.Value, typ reflect.Type) setter {
 		return nil
 	}
 }
+
+// ptrSetter returns a setter that allocates a new value for a pointer field when set, so that a nil pointer
+// can be used to represent a value that was not configured
+func ptrSetter(f reflect.Value, typ reflect.Type, opts setterOptions) (setter, error) {
+	// ensure the element type is supported before any values are set
+	if _, err := getSetter(reflect.New(typ.Elem()).Elem(), opts); err != nil {
+		return nil, err
+	}
+
+	return func(s string) error {
+		val := reflect.New(typ.Elem())
+
+		fs, err := getSetter(val.Elem(), opts)
+		if err != nil {
+			return err
+		}
+
+		if err := fs(s); err != nil {
+			return err
+		}
+
+		f.Set(val)
+		return nil
+	}, nil
+}
+
+// mapSetter returns a setter for map fields, parsing values in the form key:value,key2:value2. The separator
+// between pairs and between keys and values can be overridden with the separator and kvseparator tags
+func mapSetter(f reflect.Value, typ reflect.Type, opts setterOptions) (setter, error) {
+	// ensure the key and element types are supported before any values are set
+	if _, err := getSetter(reflect.New(typ.Key()).Elem(), opts); err != nil {
+		return nil, err
+	}
+
+	if _, err := getSetter(reflect.New(typ.Elem()).Elem(), opts); err != nil {
+		return nil, err
+	}
+
+	sep := getTag(opts.tag, separatorTag, defaultSeparator)
+	kvSep := getTag(opts.tag, kvSeparatorTag, defaultKVSeparator)
+
+	return func(s string) error {
+		m := reflect.MakeMap(typ)
+
+		if len(strings.TrimSpace(s)) > 0 {
+			for _, pair := range strings.Split(s, sep) {
+				kv := strings.SplitN(pair, kvSep, 2)
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid map entry %q, expected key%svalue", pair, kvSep)
+				}
+
+				key := reflect.New(typ.Key()).Elem()
+				if err := setValue(key, opts, kv[0]); err != nil {
+					return err
+				}
+
+				val := reflect.New(typ.Elem()).Elem()
+				if err := setValue(val, opts, kv[1]); err != nil {
+					return err
+				}
+
+				m.SetMapIndex(key, val)
+			}
+		}
+
+		f.Set(m)
+		return nil
+	}, nil
+}
+
+// setValue sets a single value using the setter for its type
+func setValue(f reflect.Value, opts setterOptions, s string) error {
+	fs, err := getSetter(f, opts)
+	if err != nil {
+		return err
+	}
+
+	return fs(s)
+}
+
+// getTag returns the value of the given tag, or the default value if the tag is not set
+func getTag(tag reflect.StructTag, key, defaultValue string) string {
+	if val, ok := tag.Lookup(key); ok && val != "" {
+		return val
+	}
+
+	return defaultValue
+}
+
+// valuesSetter sets a field from a set of named values, tagKey is the tag key of the source providing the values
+type valuesSetter func(values map[string]string, tagKey string) error
+
+// getValuesSetter returns a setter for fields that can be set from a set of named values, such as every parameter
+// beneath a path in a hierarchical source. Maps are set from every name and value, and structs are set by matching
+// names to fields. Nil is returned for any other type
+func getValuesSetter(f reflect.Value, opts setterOptions) valuesSetter {
+	typ := f.Type()
+	if opts.hasCustomSetter(typ) {
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Map:
+		if _, err := mapSetter(f, typ, opts); err != nil {
+			return nil
+		}
+
+		return mapValuesSetter(f, typ, opts)
+	case reflect.Struct:
+		return structValuesSetter(f, typ, opts)
+	case reflect.Ptr:
+		if typ.Elem().Kind() != reflect.Struct || opts.hasCustomSetter(typ.Elem()) {
+			return nil
+		}
+
+		return func(values map[string]string, tagKey string) error {
+			val := reflect.New(typ.Elem())
+			if err := structValuesSetter(val.Elem(), typ.Elem(), opts)(values, tagKey); err != nil {
+				return err
+			}
+
+			f.Set(val)
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+// mapValuesSetter returns a values setter that sets a map field from every name and value
+func mapValuesSetter(f reflect.Value, typ reflect.Type, opts setterOptions) valuesSetter {
+	return func(values map[string]string, _ string) error {
+		m := reflect.MakeMapWithSize(typ, len(values))
+
+		for name, v := range values {
+			key := reflect.New(typ.Key()).Elem()
+			if err := setValue(key, opts, name); err != nil {
+				return err
+			}
+
+			val := reflect.New(typ.Elem()).Elem()
+			if err := setValue(val, opts, v); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+
+			m.SetMapIndex(key, val)
+		}
+
+		f.Set(m)
+		return nil
+	}
+}
+
+// structValuesSetter returns a values setter that sets the fields of a struct from the values with matching names.
+// A field matches the name in its tag for the source, or its field name if it has no tag, ignoring case. Names
+// containing a / address fields of nested structs and maps, and values that match no field are ignored
+func structValuesSetter(f reflect.Value, typ reflect.Type, opts setterOptions) valuesSetter {
+	return func(values map[string]string, tagKey string) error {
+		for i := 0; i < typ.NumField(); i++ {
+			sf := typ.Field(i)
+			if sf.PkgPath != "" {
+				// unexported field
+				continue
+			}
+
+			name := sf.Name
+			if tagValue, ok := sf.Tag.Lookup(tagKey); ok && tagValue != "" {
+				name = strings.Split(tagValue, ",")[0]
+			}
+
+			fieldOpts := setterOptions{tag: sf.Tag, parsers: opts.parsers}
+			fieldValue := f.Field(i)
+
+			if fn := getValuesSetter(fieldValue, fieldOpts); fn != nil {
+				nested := nestedValues(values, name)
+				if len(nested) == 0 {
+					continue
+				}
+
+				if err := fn(nested, tagKey); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+
+				continue
+			}
+
+			for k, v := range values {
+				if !strings.EqualFold(k, name) {
+					continue
+				}
+
+				if err := setValue(fieldValue, fieldOpts, v); err != nil {
+					return fmt.Errorf("%s: %w", k, err)
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// nestedValues returns the values beneath the given name, with the name and separator trimmed from their names
+func nestedValues(values map[string]string, name string) map[string]string {
+	nested := make(map[string]string)
+
+	for k, v := range values {
+		i := strings.Index(k, "/")
+		if i < 0 || !strings.EqualFold(k[:i], name) {
+			continue
+		}
+
+		nested[k[i+1:]] = v
+	}
+
+	return nested
+}
+
+// listSetter sets a field from a list of values
+type listSetter func(values []string) error
+
+// getListSetter returns a setter for slice fields that sets every element from a value in a list, for sources with
+// native list types. Nil is returned for any other type, as well as byte slices and types with a custom setter
+func getListSetter(f reflect.Value, opts setterOptions) listSetter {
+	typ := f.Type()
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() == reflect.Uint8 || opts.hasCustomSetter(typ) {
+		return nil
+	}
+
+	if _, err := getSetter(reflect.New(typ.Elem()).Elem(), opts); err != nil {
+		return nil
+	}
+
+	return func(values []string) error {
+		sl := reflect.MakeSlice(typ, len(values), len(values))
+
+		for i, val := range values {
+			if err := setValue(sl.Index(i), opts, val); err != nil {
+				return err
+			}
+		}
+
+		f.Set(sl)
+		return nil
+	}
+}
+
+// valuesOnlySetter returns a setter for fields that can only be set from a set of named values, such as structs
+// loaded from a hierarchical source
+func valuesOnlySetter(typ reflect.Type) setter {
+	return func(string) error {
+		return fmt.Errorf("%w: %s can only be set from a set of named values", ErrUnsupportedType, typ)
+	}
+}
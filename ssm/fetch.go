@@ -0,0 +1,228 @@
+package ssm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseDelay = 100 * time.Millisecond
+	defaultMaxDelay  = 5 * time.Second
+)
+
+// RetryOptions configures how requests to the parameter store are retried when they are throttled or fail with a
+// server error. Delays grow exponentially from BaseDelay up to MaxDelay, with full jitter applied to each delay
+type RetryOptions struct {
+	// MaxRetries is the maximum number of times a request is retried, requests are not retried if zero
+	MaxRetries int
+
+	// BaseDelay is the delay before the first retry, defaults to 100ms
+	BaseDelay time.Duration
+
+	// MaxDelay is the maximum delay between retries, defaults to 5s
+	MaxDelay time.Duration
+}
+
+// delay returns the jittered delay before the given retry, starting at zero
+func (o RetryOptions) delay(retry int) time.Duration {
+	base := o.BaseDelay
+	if base <= 0 {
+		base = defaultBaseDelay
+	}
+
+	max := o.MaxDelay
+	if max <= 0 {
+		max = defaultMaxDelay
+	}
+
+	d := base
+	for i := 0; i < retry && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// throttleCodes holds the error codes returned by AWS when requests are throttled
+var throttleCodes = map[string]bool{
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"TooManyRequestsException":               true,
+	"RequestLimitExceeded":                   true,
+	"ProvisionedThroughputExceededException": true,
+	"InternalServerError":                    true,
+}
+
+// isRetryable returns true if the error is a throttling error or a server error
+func isRetryable(err error) bool {
+	var coder interface{ ErrorCode() string }
+	if errors.As(err, &coder) && throttleCodes[coder.ErrorCode()] {
+		return true
+	}
+
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		code := status.HTTPStatusCode()
+		return code == 429 || code >= 500
+	}
+
+	return false
+}
+
+// limiter is a token bucket rate limiter, allowing up to burst requests at once and refilling at rate tokens per
+// second
+type limiter struct {
+	mu sync.Mutex
+
+	rate  float64
+	burst float64
+
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done. A nil limiter never blocks
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// take the token now, waiting for it to be refilled if the bucket is empty
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+
+	l.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// fetcher makes requests to the parameter store, applying the rate limit and retrying failed requests
+type fetcher struct {
+	limiter *limiter
+	retry   RetryOptions
+}
+
+// do calls fn, waiting for the rate limit before every attempt and retrying throttling and server errors
+func (f *fetcher) do(ctx context.Context, fn func() error) error {
+	for retry := 0; ; retry++ {
+		if err := f.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || retry >= f.retry.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		if err := sleep(ctx, f.retry.delay(retry)); err != nil {
+			return err
+		}
+	}
+}
+
+// forEach calls fn for every index up to n, running up to concurrency calls at once. The first error cancels the
+// context passed to the remaining calls and is returned once all calls have finished
+func forEach(ctx context.Context, n, concurrency int, fn func(context.Context, int) error) error {
+	if n == 0 {
+		return nil
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	indexes := make(chan int)
+
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
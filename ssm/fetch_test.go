@@ -0,0 +1,186 @@
+package ssm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIError struct {
+	code string
+}
+
+func (e *mockAPIError) Error() string {
+	return e.code
+}
+
+func (e *mockAPIError) ErrorCode() string {
+	return e.code
+}
+
+type mockStatusError struct {
+	status int
+}
+
+func (e *mockStatusError) Error() string {
+	return fmt.Sprintf("status %d", e.status)
+}
+
+func (e *mockStatusError) HTTPStatusCode() int {
+	return e.status
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&mockAPIError{code: "ThrottlingException"}))
+	assert.True(t, isRetryable(fmt.Errorf("wrapped: %w", &mockAPIError{code: "TooManyRequestsException"})))
+	assert.True(t, isRetryable(&mockStatusError{status: 503}))
+	assert.True(t, isRetryable(&mockStatusError{status: 429}))
+	assert.False(t, isRetryable(&mockStatusError{status: 400}))
+	assert.False(t, isRetryable(&mockAPIError{code: "ParameterNotFound"}))
+	assert.False(t, isRetryable(errors.New("test error")))
+}
+
+func TestRetryOptions_delay(t *testing.T) {
+	opts := RetryOptions{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, opts.delay(0) <= 10*time.Millisecond)
+		assert.True(t, opts.delay(2) <= 40*time.Millisecond)
+		assert.True(t, opts.delay(10) <= 50*time.Millisecond)
+		assert.True(t, opts.delay(100) >= 0)
+	}
+
+	assert.True(t, RetryOptions{}.delay(100) <= defaultMaxDelay)
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(100, 2)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, l.wait(context.Background()))
+	}
+
+	// two requests are allowed at once, the remaining four wait 10ms each
+	assert.True(t, time.Since(start) >= 35*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(l.wait(ctx), context.Canceled))
+
+	assert.Nil(t, newLimiter(0, 1))
+	assert.NoError(t, (*limiter)(nil).wait(context.Background()))
+}
+
+func TestSource_ProcessConcurrency(t *testing.T) {
+	mock := &mockSsm{
+		params: make(map[string]string, 80),
+		delay:  10 * time.Millisecond,
+	}
+
+	paramMap := make(map[string][]config.Parameter, 80)
+	params := make([]*mockParameter, 80)
+	for i := range params {
+		key := fmt.Sprintf("key-%d", i)
+		mock.params["/svc/"+key] = fmt.Sprintf("value-%d", i)
+
+		params[i] = &mockParameter{expectVal: true}
+		paramMap[key] = []config.Parameter{params[i]}
+	}
+
+	src := New("/svc/", mock)
+	src.Concurrency = 4
+
+	assert.NoError(t, src.Process(paramMap))
+	assert.Equal(t, 8, mock.calls)
+	assert.True(t, mock.maxInFlight > 1)
+	assert.True(t, mock.maxInFlight <= 4)
+
+	for i, p := range params {
+		assert.Equal(t, fmt.Sprintf("value-%d", i), p.val)
+	}
+}
+
+func TestSource_ProcessRetry(t *testing.T) {
+	newSource := func(mock *mockSsm) *Source {
+		src := New("/svc/", mock)
+		src.Retry = RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond}
+		return src
+	}
+
+	t.Run("Retried", func(t *testing.T) {
+		mock := &mockSsm{
+			params: map[string]string{"/svc/key": "value"},
+			failures: []error{
+				&mockAPIError{code: "ThrottlingException"},
+				&mockStatusError{status: 500},
+			},
+		}
+
+		p := &mockParameter{expectVal: true}
+		assert.NoError(t, newSource(mock).Process(map[string][]config.Parameter{"key": {p}}))
+		assert.Equal(t, "value", p.val)
+		assert.Equal(t, 3, mock.calls)
+	})
+
+	t.Run("TooManyRetries", func(t *testing.T) {
+		mock := &mockSsm{
+			params: map[string]string{"/svc/key": "value"},
+			failures: []error{
+				&mockAPIError{code: "ThrottlingException"},
+				&mockAPIError{code: "ThrottlingException"},
+				&mockAPIError{code: "ThrottlingException"},
+			},
+		}
+
+		err := newSource(mock).Process(map[string][]config.Parameter{"key": {&mockParameter{}}})
+		assert.Error(t, err)
+		assert.Equal(t, 3, mock.calls)
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		mock := &mockSsm{
+			failures: []error{errors.New("test error")},
+		}
+
+		err := newSource(mock).Process(map[string][]config.Parameter{"key": {&mockParameter{}}})
+		assert.Error(t, err)
+		assert.Equal(t, 1, mock.calls)
+	})
+
+	t.Run("NotRetriedByDefault", func(t *testing.T) {
+		mock := &mockSsm{
+			failures: []error{&mockAPIError{code: "ThrottlingException"}},
+		}
+
+		err := New("/svc/", mock).Process(map[string][]config.Parameter{"key": {&mockParameter{}}})
+		assert.Error(t, err)
+		assert.Equal(t, 1, mock.calls)
+	})
+}
+
+func TestSource_ProcessRateLimit(t *testing.T) {
+	mock := &mockSsm{params: map[string]string{}}
+
+	paramMap := make(map[string][]config.Parameter, 50)
+	for i := 0; i < 50; i++ {
+		paramMap[fmt.Sprintf("key-%d", i)] = []config.Parameter{&mockParameter{}}
+	}
+
+	src := New("/svc/", mock)
+	src.Concurrency = 5
+	src.RateLimit = 50
+	src.Burst = 1
+
+	start := time.Now()
+	assert.NoError(t, src.Process(paramMap))
+
+	// five batches at 50 requests per second, after the first request is made immediately
+	assert.True(t, time.Since(start) >= 75*time.Millisecond)
+	assert.Equal(t, 5, mock.calls)
+}
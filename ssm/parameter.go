@@ -0,0 +1,89 @@
+package ssm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/onetwentyseven-dev/go-config"
+)
+
+// TypeMismatchError is returned when ValidateTypes is set and the type of a parameter does not match the type of
+// the field it is loaded into. StringList parameters must be loaded into slice fields, and slice fields must be
+// loaded from StringList parameters
+type TypeMismatchError struct {
+	Name      string
+	Type      types.ParameterType
+	FieldType reflect.Type
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("ssm parameter %s of type %s can not be loaded into a field of type %s", e.Name, e.Type, e.FieldType)
+}
+
+// setParameter sets the value of a parameter fetched by name, using the type of the parameter to split StringList
+// values natively and to mark SecureString values as secret
+func (s *Source) setParameter(p config.Parameter, name string, param types.Parameter) error {
+	if param.Value == nil {
+		return p.SetValue("")
+	}
+
+	if param.Type == types.ParameterTypeSecureString {
+		markSecret(p)
+	}
+
+	if s.ValidateTypes {
+		if err := validateType(p, name, param.Type); err != nil {
+			// record the mismatch on the field, so that it is not reported as missing or set to its default
+			if ep, ok := p.(config.ErrorParameter); ok {
+				return ep.SetError(err)
+			}
+
+			return err
+		}
+	}
+
+	if lp, ok := p.(config.ListParameter); ok && param.Type == types.ParameterTypeStringList {
+		return lp.SetList(strings.Split(aws.ToString(param.Value), ","))
+	}
+
+	return p.SetValue(aws.ToString(param.Value))
+}
+
+// markSecret marks the parameter as secret, if supported
+func markSecret(p config.Parameter) {
+	if sp, ok := p.(config.SecretParameter); ok {
+		sp.MarkSecret()
+	}
+}
+
+// validateType returns a TypeMismatchError if the type of the parameter does not match the field. Parameters
+// without a type, or that do not expose the type of their field, are not validated
+func validateType(p config.Parameter, name string, typ types.ParameterType) error {
+	tp, ok := p.(config.TypedParameter)
+	if !ok || typ == "" {
+		return nil
+	}
+
+	fieldType := tp.Type()
+
+	elem := fieldType
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	// byte slices hold a single value rather than a list
+	isList := elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8
+
+	if isList == (typ == types.ParameterTypeStringList) {
+		return nil
+	}
+
+	return &TypeMismatchError{
+		Name:      name,
+		Type:      typ,
+		FieldType: fieldType,
+	}
+}
@@ -0,0 +1,154 @@
+package ssm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTypedStore(t *testing.T) *MemoryStore {
+	store, err := NewMemoryStore(map[string]StoredParameter{
+		"/svc/hosts":        {Value: "a;1,b;2", Type: types.ParameterTypeStringList},
+		"/svc/ports":        {Value: "80,443", Type: types.ParameterTypeStringList},
+		"/svc/name":         {Value: "service"},
+		"/svc/password":     {Value: "hunter2", Type: types.ParameterTypeSecureString},
+		"/svc/db/host":      {Value: "db.local"},
+		"/svc/db/password":  {Value: "secret", Type: types.ParameterTypeSecureString},
+		"/svc/invalid-port": {Value: "hunter2", Type: types.ParameterTypeSecureString},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestSource_ProcessTypes(t *testing.T) {
+	store := newTypedStore(t)
+
+	var cfg struct {
+		Hosts    []string          `ssm:"hosts" separator:";"`
+		Ports    []int             `ssm:"ports"`
+		Joined   string            `ssm:"hosts"`
+		Name     string            `ssm:"name"`
+		Password string            `ssm:"password"`
+		Database map[string]string `ssm:"db/,path"`
+	}
+
+	report, err := config.ProcessWithReport(&cfg, New("/svc/", store))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"a;1", "b;2"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, "a;1,b;2", cfg.Joined)
+	assert.Equal(t, "service", cfg.Name)
+	assert.Equal(t, "hunter2", cfg.Password)
+	assert.Equal(t, map[string]string{"host": "db.local", "password": "secret"}, cfg.Database)
+
+	// SecureString parameters are redacted, including any path containing one
+	values := make(map[string]string, len(report.Fields))
+	for _, f := range report.Fields {
+		values[f.Field] = f.Value
+	}
+
+	assert.Equal(t, "service", values["Name"])
+	assert.Equal(t, "******", values["Password"])
+	assert.Equal(t, "******", values["Database"])
+	assert.NotContains(t, report.String(), "hunter2")
+	assert.NotContains(t, report.String(), "secret")
+}
+
+func TestSource_ProcessSecureStringError(t *testing.T) {
+	var cfg struct {
+		Port int `ssm:"invalid-port"`
+	}
+
+	err := config.Process(&cfg, New("/svc/", newTypedStore(t)))
+	assert.True(t, errors.Is(err, config.ErrInvalidValue))
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestSource_ProcessValidateTypes(t *testing.T) {
+	store := newTypedStore(t)
+
+	newSource := func() *Source {
+		src := New("/svc/", store)
+		src.ValidateTypes = true
+		return src
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		var cfg struct {
+			Hosts    []string `ssm:"hosts"`
+			Name     string   `ssm:"name"`
+			Password []byte   `ssm:"password"`
+		}
+
+		assert.NoError(t, config.Process(&cfg, newSource()))
+	})
+
+	t.Run("ListIntoScalar", func(t *testing.T) {
+		var cfg struct {
+			Ports int `ssm:"ports"`
+		}
+
+		err := config.Process(&cfg, newSource())
+
+		var mismatch *TypeMismatchError
+		if assert.True(t, errors.As(err, &mismatch)) {
+			assert.Equal(t, "/svc/ports", mismatch.Name)
+			assert.Equal(t, types.ParameterTypeStringList, mismatch.Type)
+			assert.Equal(t, reflect.TypeOf(0), mismatch.FieldType)
+		}
+	})
+
+	t.Run("ScalarIntoList", func(t *testing.T) {
+		var cfg struct {
+			Names *[]string `ssm:"name"`
+		}
+
+		err := config.Process(&cfg, newSource())
+
+		var mismatch *TypeMismatchError
+		assert.True(t, errors.As(err, &mismatch))
+		assert.Contains(t, err.Error(), "ssm parameter /svc/name of type String can not be loaded into a field of type *[]string")
+	})
+
+	t.Run("RequiredField", func(t *testing.T) {
+		var cfg struct {
+			Ports    int `ssm:"ports" required:"true"`
+			Defaults int `ssm:"hosts" default:"1"`
+		}
+
+		report, err := config.ProcessWithReport(&cfg, newSource())
+
+		// the mismatch is reported as a parse error on the field, not as a missing value or a default
+		assert.False(t, errors.Is(err, config.ErrMissingValue))
+		assert.Equal(t, 0, cfg.Defaults)
+
+		var processErr *config.ProcessError
+		if assert.True(t, errors.As(err, &processErr)) && assert.Len(t, processErr.Fields(), 2) {
+			for _, fieldErr := range processErr.Fields() {
+				assert.Equal(t, config.Parse, fieldErr.Kind)
+				assert.Equal(t, "ssm", fieldErr.Source)
+
+				var mismatch *TypeMismatchError
+				assert.True(t, errors.As(fieldErr, &mismatch))
+			}
+		}
+
+		if assert.Len(t, report.Fields, 2) {
+			for _, f := range report.Fields {
+				assert.False(t, f.Default)
+				assert.Equal(t, "ssm", f.Source)
+				assert.Error(t, f.Err)
+			}
+		}
+	})
+}
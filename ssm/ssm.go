@@ -5,23 +5,87 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/hashicorp/go-multierror"
 	"github.com/onetwentyseven-dev/go-config"
 	"github.com/pkg/errors"
 )
 
-var _ config.Source = new(Source)
+var (
+	_ config.ContextSource = new(Source)
+	_ config.KeyResolver   = new(Source)
+	_ config.ValuesSource  = new(Source)
+)
 
 // ParamStore represents the Systems Manager Client methods needed by the ssm config source
 type ParamStore interface {
 	GetParameters(context.Context, *ssm.GetParametersInput, ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
 }
 
+// PathParamStore represents the Systems Manager Client methods needed to load every parameter beneath a path. It
+// is only required for tags with the path option, i.e. `ssm:"/svc/feature-flags/,path"`
+type PathParamStore interface {
+	GetParametersByPath(context.Context, *ssm.GetParametersByPathInput, ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+// NameCase normalises the case of parameter names before they are fetched. Parameter names are case sensitive, so
+// the normaliser must match how parameters are named in the parameter store
+type NameCase func(string) string
+
+var (
+	// LowerCase lowers the case of the prefix and parameter names
+	LowerCase NameCase = strings.ToLower
+
+	// PreserveCase uses parameter names exactly as they are written in tags and the prefix
+	PreserveCase NameCase = func(name string) string {
+		return name
+	}
+)
+
+// InvalidParametersError is returned in strict mode when parameters requested by name do not exist in the
+// parameter store, which usually means a name is misspelled
+type InvalidParametersError struct {
+	Names []string
+}
+
+func (e *InvalidParametersError) Error() string {
+	return fmt.Sprintf("invalid ssm parameters: %s", strings.Join(e.Names, ", "))
+}
+
 // Source is a source that pulls parameters from AWS Parameter Store
 type Source struct {
 	Prefix string
 	Ssm    ParamStore
+
+	// NameCase normalises the case of the prefix and parameter names. If nil, parameter names are lowered and the
+	// prefix is used as written
+	NameCase NameCase
+
+	// OnInvalidParameters is called with the names of any requested parameters that do not exist, i.e. to log a
+	// warning. Fields for these parameters are treated as missing unless Strict is set
+	OnInvalidParameters func(names []string)
+
+	// Strict causes an InvalidParametersError to be returned if any requested parameter does not exist, rather than
+	// treating it as a missing value that falls back to other sources or the default
+	Strict bool
+
+	// Concurrency is the maximum number of batches of parameters fetched at once, batches are fetched one at a time
+	// if zero
+	Concurrency int
+
+	// RateLimit is the maximum number of requests per second made to the parameter store during a call to Process,
+	// with up to Burst requests made at once. Requests are not rate limited if zero
+	RateLimit float64
+	Burst     int
+
+	// Retry configures retries of requests that are throttled or fail with a server error
+	Retry RetryOptions
+
+	// ValidateTypes causes a TypeMismatchError to be returned when the type of a parameter does not match the field
+	// it is loaded into, i.e. a String parameter loaded into a slice field or a StringList loaded into an int field
+	ValidateTypes bool
 }
 
 // New creates a new source
@@ -37,90 +101,335 @@ func (s *Source) TagKey() string {
 	return "ssm"
 }
 
-func (s *Source) getParameters(names []string) (map[string]string, error) {
-	parameters := make([]types.Parameter, 0, len(names))
+// batchSize is the maximum number of names accepted by a single GetParameters call
+const batchSize = 10
+
+// batchNames splits the names into batches for GetParameters. A parameter may only be requested once per call, so
+// names selecting different versions or labels of the same parameter are placed in separate batches
+func batchNames(names []string) [][]string {
+	var (
+		batches [][]string
+		bases   []map[string]bool
+	)
+
+	for _, name := range names {
+		base, _ := splitSelector(name)
+
+		i := 0
+		for ; i < len(batches); i++ {
+			if len(batches[i]) < batchSize && !bases[i][base] {
+				break
+			}
+		}
 
-	for i := 0; i < len(names); i += 10 {
-		end := i + 10
-		if end > len(names) {
-			end = len(names)
+		if i == len(batches) {
+			batches = append(batches, make([]string, 0, batchSize))
+			bases = append(bases, make(map[string]bool, batchSize))
 		}
 
-		// TODO: should we get the context from somewhere?
-		response, err := s.Ssm.GetParameters(context.Background(), &ssm.GetParametersInput{
-			Names:          names[i:end],
-			WithDecryption: true,
+		batches[i] = append(batches[i], name)
+		bases[i][base] = true
+	}
+
+	return batches
+}
+
+// newFetcher returns the fetcher used for the requests made during a single call to Process
+func (s *Source) newFetcher() *fetcher {
+	return &fetcher{
+		limiter: newLimiter(s.RateLimit, s.Burst),
+		retry:   s.Retry,
+	}
+}
+
+// getParameters returns the named parameters, along with the names of any parameters that do not exist.
+// Names may include a version or label selector, i.e. /svc/key:7, and parameters are keyed by the name as requested
+func (s *Source) getParameters(ctx context.Context, f *fetcher, names []string) (map[string]types.Parameter, []string, error) {
+	batches := batchNames(names)
+	responses := make([]*ssm.GetParametersOutput, len(batches))
+
+	err := forEach(ctx, len(batches), s.Concurrency, func(ctx context.Context, i int) error {
+		return f.do(ctx, func() error {
+			response, err := s.Ssm.GetParameters(ctx, &ssm.GetParametersInput{
+				Names:          batches[i],
+				WithDecryption: true,
+			})
+			if err != nil {
+				return errors.Wrap(err, "error fetching items from param store")
+			}
+
+			responses[i] = response
+			return nil
 		})
-		if err != nil {
-			return nil, errors.Wrap(err, "error fetching items from param store")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[string]types.Parameter, len(names))
+
+	var invalid []string
+
+	for _, response := range responses {
+		invalid = append(invalid, response.InvalidParameters...)
+
+		for _, p := range response.Parameters {
+			if p.Name == nil || p.Value == nil {
+				continue
+			}
+
+			// the name of a parameter never includes the selector, which is returned separately
+			name := *p.Name
+			if selector := aws.ToString(p.Selector); selector != "" {
+				name += ":" + strings.TrimPrefix(selector, ":")
+			}
+
+			result[name] = p
 		}
+	}
+
+	return result, invalid, nil
+}
 
-		parameters = append(parameters, response.Parameters...)
+// getParametersByPath returns every parameter beneath the path, recursively, keyed by their names relative to the path.
+// The returned bool is true if any of the parameters is a SecureString
+func (s *Source) getParametersByPath(ctx context.Context, f *fetcher, path string) (map[string]string, bool, error) {
+	store, ok := s.Ssm.(PathParamStore)
+	if !ok {
+		return nil, false, errors.New("the ssm client does not support loading parameters by path")
 	}
 
-	result := make(map[string]string, len(parameters))
-	for _, p := range parameters {
-		if p.Name == nil || p.Value == nil {
-			continue
+	// the path is requested without a trailing slash, which is trimmed from the names of the parameters
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		path = "/"
+	}
+
+	trim := strings.TrimSuffix(path, "/") + "/"
+	result := make(map[string]string)
+	secure := false
+
+	var nextToken *string
+
+	for {
+		var response *ssm.GetParametersByPathOutput
+
+		// the fetcher checks for cancellation before every page so a slow or large fetch can be aborted
+		err := f.do(ctx, func() error {
+			var err error
+			response, err = store.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+				Path:           aws.String(path),
+				Recursive:      true,
+				WithDecryption: true,
+				NextToken:      nextToken,
+			})
+
+			return err
+		})
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "error fetching items beneath %s from param store", path)
+		}
+
+		for _, p := range response.Parameters {
+			if p.Name == nil || p.Value == nil {
+				continue
+			}
+
+			result[strings.TrimPrefix(*p.Name, trim)] = *p.Value
+			secure = secure || p.Type == types.ParameterTypeSecureString
 		}
 
-		result[*p.Name] = *p.Value
+		nextToken = response.NextToken
+		if nextToken == nil || *nextToken == "" {
+			break
+		}
 	}
 
-	return result, nil
+	return result, secure, nil
 }
 
 // Process handles processing of ssm configuration parameters
 func (s *Source) Process(paramMap map[string][]config.Parameter) error {
-	names := make([]string, 0, len(paramMap))
-	handlers := make(map[string][]config.Parameter, len(paramMap))
+	return s.ProcessContext(context.Background(), paramMap)
+}
+
+// ProcessContext handles processing of ssm configuration parameters, aborting if the context is done. Tags with the
+// path option load every parameter beneath the path into a map or struct field, other tags load a single parameter
+func (s *Source) ProcessContext(ctx context.Context, paramMap map[string][]config.Parameter) error {
+	keys := config.OrderedKeys(paramMap)
+	names := make([]string, 0, len(keys))
+	handlers := make(map[string][]config.Parameter, len(keys))
 
-	for name, params := range paramMap {
-		name = getParamName(name, s.Prefix)
+	var (
+		paths        []string
+		pathHandlers = make(map[string][]config.Parameter)
+	)
 
-		names = append(names, name)
-		handlers[name] = params
+	for _, key := range keys {
+		name := s.getParamName(key)
+
+		if isPath(key) {
+			if _, ok := pathHandlers[name]; !ok {
+				paths = append(paths, name)
+			}
+
+			pathHandlers[name] = append(pathHandlers[name], paramMap[key]...)
+			continue
+		}
+
+		if _, ok := handlers[name]; !ok {
+			names = append(names, name)
+		}
+
+		handlers[name] = append(handlers[name], paramMap[key]...)
 	}
 
-	parameters, err := s.getParameters(names)
+	f := s.newFetcher()
+
+	parameters, invalid, err := s.getParameters(ctx, f, names)
 	if err != nil {
 		return errors.Wrap(err, "error getting parameters")
 	}
 
-	for name, params := range handlers {
-		val := parameters[name]
+	var errs *multierror.Error
+
+	if len(invalid) > 0 {
+		if s.OnInvalidParameters != nil {
+			s.OnInvalidParameters(invalid)
+		}
+
+		if s.Strict {
+			errs = multierror.Append(errs, &InvalidParametersError{Names: invalid})
+		}
+	}
+
+	for _, path := range paths {
+		values, secure, err := s.getParametersByPath(ctx, f, path)
+		if err != nil {
+			// a failed path doesn't prevent the remaining parameters from being set
+			errs = multierror.Append(errs, errors.Wrap(err, "error getting parameters by path"))
+
+			for _, p := range pathHandlers[path] {
+				errs = multierror.Append(errs, p.NoValue())
+			}
+
+			continue
+		}
+
+		for _, p := range pathHandlers[path] {
+			vp, ok := p.(config.ValuesParameter)
+			if !ok {
+				errs = multierror.Append(errs, fmt.Errorf("error: parameter for path %s can not be set from a set of values", path))
+				continue
+			}
 
-		for _, p := range params {
-			if err := p.SetValue(val); err != nil {
-				return err
+			if secure {
+				markSecret(p)
 			}
+
+			errs = multierror.Append(errs, vp.SetValues(values))
+		}
+	}
+
+	for _, name := range names {
+		param := parameters[name]
+
+		for _, p := range handlers[name] {
+			errs = multierror.Append(errs, s.setParameter(p, name, param))
 		}
 	}
 
-	return nil
+	return errs.ErrorOrNil()
+}
+
+// ResolveKey returns the name of the parameter for a tag value, applying the prefix, case handling and absolute option
+func (s *Source) ResolveKey(tagValue string) string {
+	return s.getParamName(tagValue)
 }
 
-func getParamName(tagValue, prefix string) string {
-	tagValue = strings.TrimSpace(strings.ToLower(tagValue))
-	parts := strings.Split(tagValue, ",")
+// IsValuesKey returns true if the tag value has the path option, loading every parameter beneath the path
+func (s *Source) IsValuesKey(tagValue string) bool {
+	return isPath(tagValue)
+}
 
-	var absolute bool
-	if len(parts) > 1 {
-		absolute = contains(parts[1:], "absolute")
+// parseTag returns the name and options of a tag value, i.e. /svc/feature-flags/,path. The case of the name is
+// left to the NameCase of the source
+func parseTag(tagValue string) (string, []string) {
+	parts := strings.Split(strings.TrimSpace(tagValue), ",")
+
+	options := make([]string, len(parts)-1)
+	for i, option := range parts[1:] {
+		options[i] = strings.TrimSpace(option)
 	}
 
-	result := parts[0]
+	return parts[0], options
+}
+
+// isPath returns true if the tag value loads every parameter beneath a path
+func isPath(tagValue string) bool {
+	_, options := parseTag(tagValue)
+	return contains(options, "path")
+}
 
-	if absolute || strings.HasPrefix(result, prefix) {
-		return result
+// splitSelector splits a parameter name into the name and its version or label selector, if any. Names may be
+// ARNs, which contain colons of their own, so only a colon following the last / starts the selector
+func splitSelector(name string) (string, string) {
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		return name[:i], name[i+1:]
+	}
+
+	return name, ""
+}
+
+// getParamName returns the name of the parameter for a tag value, including any version or label selector. If a
+// NameCase is set, the case of both the prefix and the name is normalised before they are joined, so the prefix is
+// only added once regardless of how either is written. Otherwise only the name is lowered and the prefix is used as
+// written. Selectors are case sensitive and left as they are
+func (s *Source) getParamName(tagValue string) string {
+	normalise, prefix := s.NameCase, s.Prefix
+	if normalise == nil {
+		normalise = LowerCase
+	} else {
+		prefix = normalise(prefix)
+	}
+
+	result, options := parseTag(tagValue)
+	result, selector := splitSelector(result)
+
+	// a label option takes the place of any selector in the name
+	if label, ok := optionValue(options, "label"); ok {
+		selector = label
+	}
+
+	result = normalise(result)
+
+	if !contains(options, "absolute") && !strings.HasPrefix(result, prefix) {
+		result = fmt.Sprintf("%s%s", prefix, result)
+	}
+
+	if selector != "" {
+		result = fmt.Sprintf("%s:%s", result, selector)
+	}
+
+	return result
+}
+
+// optionValue returns the value of a key=value option, matching the key regardless of case
+func optionValue(options []string, key string) (string, bool) {
+	for _, option := range options {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), key) {
+			return strings.TrimSpace(kv[1]), true
+		}
 	}
 
-	return fmt.Sprintf("%s%s", prefix, result)
+	return "", false
 }
 
+// contains returns true if the options contain the needle, regardless of case
 func contains(haystack []string, needle string) bool {
 	for _, v := range haystack {
-		if v == needle {
+		if strings.EqualFold(v, needle) {
 			return true
 		}
 	}
@@ -3,7 +3,13 @@ package ssm
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -39,28 +45,145 @@ func (m *mockParameter) AssertExpectations(t *testing.T) {
 type mockSsm struct {
 	params map[string]string
 	err    error
+
+	// pageSize limits the number of parameters returned per call to GetParametersByPath
+	pageSize  int
+	pathCalls int
+	pathErr   error
+
+	// failures are returned by the first calls to GetParameters, before any values
+	failures []error
+
+	// delay is how long each call to GetParameters takes, used to track how many calls are made at once
+	delay       time.Duration
+	inFlight    int
+	maxInFlight int
+
+	mu      sync.Mutex
+	batches [][]string
+	calls   int
+	cancel  context.CancelFunc
 }
 
 func (m *mockSsm) GetParameters(_ context.Context, in *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.cancel != nil {
+		m.cancel()
+	}
+
 	if m.err != nil {
 		return nil, m.err
 	}
 
+	if len(m.failures) > 0 {
+		err := m.failures[0]
+		m.failures = m.failures[1:]
+		return nil, err
+	}
+
+	if m.delay > 0 {
+		m.inFlight++
+		if m.inFlight > m.maxInFlight {
+			m.maxInFlight = m.inFlight
+		}
+
+		m.mu.Unlock()
+		time.Sleep(m.delay)
+		m.mu.Lock()
+
+		m.inFlight--
+	}
+
+	if len(in.Names) > 10 {
+		return nil, errors.New("too many names")
+	}
+
+	m.batches = append(m.batches, in.Names)
+
+	// parameters are keyed by name and selector, i.e. /key:7, and only one selector per parameter is allowed
+	bases := make(map[string]bool, len(in.Names))
+
 	out := ssm.GetParametersOutput{
 		Parameters: make([]types.Parameter, 0, len(in.Names)),
 	}
 	for _, n := range in.Names {
+		name, selector := n, ""
+		if i := strings.Index(n, ":"); i >= 0 {
+			name, selector = n[:i], n[i:]
+		}
+
+		if bases[name] {
+			return nil, fmt.Errorf("parameter %s requested more than once", name)
+		}
+		bases[name] = true
+
 		if p, ok := m.params[n]; ok {
-			out.Parameters = append(out.Parameters, types.Parameter{
-				Name:  aws.String(n),
+			param := types.Parameter{
+				Name:  aws.String(name),
 				Value: aws.String(p),
-			})
+			}
+			if selector != "" {
+				param.Selector = aws.String(selector)
+			}
+
+			out.Parameters = append(out.Parameters, param)
+		} else {
+			out.InvalidParameters = append(out.InvalidParameters, n)
+		}
+	}
+
+	return &out, nil
+}
+
+func (m *mockSsm) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	m.pathCalls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if m.pathErr != nil {
+		return nil, m.pathErr
+	}
+
+	names := make([]string, 0, len(m.params))
+	for n := range m.params {
+		if strings.HasPrefix(n, *in.Path+"/") {
+			names = append(names, n)
+		}
+	}
+
+	sort.Strings(names)
+
+	start := 0
+	if in.NextToken != nil {
+		start, _ = strconv.Atoi(*in.NextToken)
+	}
+
+	out := ssm.GetParametersByPathOutput{}
+	for i := start; i < len(names); i++ {
+		if m.pageSize > 0 && i == start+m.pageSize {
+			out.NextToken = aws.String(strconv.Itoa(i))
+			break
 		}
+
+		out.Parameters = append(out.Parameters, types.Parameter{
+			Name:  aws.String(names[i]),
+			Value: aws.String(m.params[names[i]]),
+		})
 	}
 
 	return &out, nil
 }
 
+// paramStoreOnly hides the GetParametersByPath method of the wrapped store
+type paramStoreOnly struct {
+	ParamStore
+}
+
 func TestSource_TagKey(t *testing.T) {
 	var src Source
 	assert.Equal(t, "ssm", src.TagKey())
@@ -71,7 +194,7 @@ func TestSource_Process(t *testing.T) {
 		name string
 
 		params map[string][]*mockParameter
-		mock   mockSsm
+		mock   *mockSsm
 		prefix string
 
 		expectErr bool
@@ -82,7 +205,7 @@ func TestSource_Process(t *testing.T) {
 				expectVal: false,
 			}},
 		},
-		mock: mockSsm{
+		mock: &mockSsm{
 			err: errors.New("test error"),
 		},
 		expectErr: true,
@@ -95,12 +218,32 @@ func TestSource_Process(t *testing.T) {
 			}},
 		},
 		prefix: "/test/prefix/",
-		mock: mockSsm{
+		mock: &mockSsm{
 			params: map[string]string{
 				"/test/prefix/key": "value",
 			},
 		},
 		expectErr: true,
+	}, {
+		name: "ErrSetParameterAll",
+		params: map[string][]*mockParameter{
+			"key": {{
+				err:       errors.New("test error"),
+				expectVal: true,
+			}},
+			"key2": {{
+				err:       errors.New("test error"),
+				expectVal: true,
+			}},
+		},
+		prefix: "/test/prefix/",
+		mock: &mockSsm{
+			params: map[string]string{
+				"/test/prefix/key":  "value",
+				"/test/prefix/key2": "value-2",
+			},
+		},
+		expectErr: true,
 	}, {
 		name: "NormalGet",
 		params: map[string][]*mockParameter{
@@ -115,7 +258,7 @@ func TestSource_Process(t *testing.T) {
 			}},
 		},
 		prefix: "/test/prefix/",
-		mock: mockSsm{
+		mock: &mockSsm{
 			params: map[string]string{
 				"/test/prefix/key":       "value",
 				"/different-prefix/key3": "value-2",
@@ -135,7 +278,7 @@ func TestSource_Process(t *testing.T) {
 				paramMap[k] = params
 			}
 
-			source := New(tc.prefix, &tc.mock)
+			source := New(tc.prefix, tc.mock)
 			err := source.Process(paramMap)
 
 			if tc.expectErr {
@@ -152,3 +295,272 @@ func TestSource_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestSource_ProcessContext(t *testing.T) {
+	paramMap := make(map[string][]config.Parameter, 25)
+	for i := 0; i < 25; i++ {
+		paramMap[fmt.Sprintf("key-%d", i)] = []config.Parameter{&mockParameter{}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// cancel the context during the first batch, the remaining batches should not be fetched
+	mock := &mockSsm{cancel: cancel}
+	source := New("/test/prefix/", mock)
+
+	err := source.ProcessContext(ctx, paramMap)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, mock.calls)
+}
+
+func TestSource_ProcessInvalidParameters(t *testing.T) {
+	mock := &mockSsm{
+		params: map[string]string{
+			"/svc/host": "localhost",
+		},
+	}
+
+	type params struct {
+		Host string `ssm:"host"`
+		Port int    `ssm:"prot" default:"8080"`
+	}
+
+	t.Run("Hook", func(t *testing.T) {
+		var invalid []string
+
+		src := New("/svc/", mock)
+		src.OnInvalidParameters = func(names []string) {
+			invalid = names
+		}
+
+		var cfg params
+		assert.NoError(t, config.Process(&cfg, src))
+		assert.Equal(t, "localhost", cfg.Host)
+		assert.Equal(t, 8080, cfg.Port)
+		assert.Equal(t, []string{"/svc/prot"}, invalid)
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		src := New("/svc/", mock)
+		src.Strict = true
+
+		var cfg params
+		err := config.Process(&cfg, src)
+
+		var invalidErr *InvalidParametersError
+		if assert.True(t, errors.As(err, &invalidErr)) {
+			assert.Equal(t, []string{"/svc/prot"}, invalidErr.Names)
+		}
+		assert.Contains(t, err.Error(), "invalid ssm parameters: /svc/prot")
+	})
+}
+
+func TestSource_ProcessSelectors(t *testing.T) {
+	mock := &mockSsm{
+		params: map[string]string{
+			"/svc/key":      "latest",
+			"/svc/key:7":    "version-7",
+			"/svc/key:Prod": "label-prod",
+			"/svc/other":    "other",
+		},
+	}
+
+	var cfg struct {
+		Latest  string `ssm:"key"`
+		Version string `ssm:"key:7"`
+		Label   string `ssm:"key,label=Prod"`
+		Pinned  string `ssm:"/svc/key:7,absolute"`
+		Other   string `ssm:"other"`
+		Missing string `ssm:"key:8" default:"default"`
+	}
+
+	assert.NoError(t, config.Process(&cfg, New("/svc/", mock)))
+	assert.Equal(t, "latest", cfg.Latest)
+	assert.Equal(t, "version-7", cfg.Version)
+	assert.Equal(t, "label-prod", cfg.Label)
+	assert.Equal(t, "version-7", cfg.Pinned)
+	assert.Equal(t, "other", cfg.Other)
+	assert.Equal(t, "default", cfg.Missing)
+
+	// every selection of the same parameter is fetched in a separate batch
+	assert.Equal(t, [][]string{
+		{"/svc/key", "/svc/other"},
+		{"/svc/key:7"},
+		{"/svc/key:Prod"},
+		{"/svc/key:8"},
+	}, mock.batches)
+}
+
+func TestBatchNames(t *testing.T) {
+	names := make([]string, 0, 25)
+	for i := 0; i < 22; i++ {
+		names = append(names, fmt.Sprintf("/key-%d", i))
+	}
+	names = append(names, "/key-0:1", "/key-0:2", "/key-1:1")
+
+	assert.Equal(t, [][]string{
+		names[:10],
+		names[10:20],
+		{"/key-20", "/key-21", "/key-0:1", "/key-1:1"},
+		{"/key-0:2"},
+	}, batchNames(names))
+
+	// colons in ARNs are not mistaken for selectors
+	arns := []string{
+		"arn:aws:ssm:us-east-1:123456789012:parameter/key-0",
+		"arn:aws:ssm:us-east-1:123456789012:parameter/key-1",
+		"arn:aws:ssm:us-east-1:123456789012:parameter/key-1:2",
+	}
+
+	assert.Equal(t, [][]string{arns[:2], arns[2:]}, batchNames(arns))
+}
+
+func TestSource_ProcessPath(t *testing.T) {
+	mock := &mockSsm{
+		params: map[string]string{
+			"/svc/feature-flags/new-checkout":  "true",
+			"/svc/feature-flags/dark-mode":     "false",
+			"/svc/feature-flags/beta/search":   "true",
+			"/svc/db/host":                     "db.local",
+			"/svc/db/port":                     "5432",
+			"/svc/db/replica/host":             "replica.local",
+			"/svc/feature-flags-other/ignored": "true",
+			"/svc/name":                        "service",
+		},
+		pageSize: 2,
+	}
+
+	var cfg struct {
+		Name     string          `ssm:"name"`
+		Flags    map[string]bool `ssm:"feature-flags/,path"`
+		Database struct {
+			Host    string
+			Port    int
+			Replica struct {
+				Host string `ssm:"host"`
+			}
+		} `ssm:"/svc/db,path,absolute"`
+		Missing map[string]string `ssm:"missing/,path"`
+	}
+
+	assert.NoError(t, config.Process(&cfg, New("/svc/", mock)))
+	assert.Equal(t, "service", cfg.Name)
+	assert.Equal(t, map[string]bool{"new-checkout": true, "dark-mode": false, "beta/search": true}, cfg.Flags)
+	assert.Equal(t, "db.local", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, "replica.local", cfg.Database.Replica.Host)
+	assert.Nil(t, cfg.Missing)
+
+	// two pages for the flags, two for the database and one for the missing path
+	assert.Equal(t, 5, mock.pathCalls)
+	assert.Equal(t, 1, mock.calls)
+
+	t.Run("Unsupported", func(t *testing.T) {
+		var cfg struct {
+			Flags map[string]bool `ssm:"feature-flags/,path"`
+		}
+
+		assert.Error(t, config.Process(&cfg, New("/svc/", paramStoreOnly{mock})))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var cfg struct {
+			Flags map[string]bool `ssm:"feature-flags/,path"`
+		}
+
+		assert.Error(t, config.Process(&cfg, New("/svc/", &mockSsm{err: errors.New("test error")})))
+	})
+
+	t.Run("PathError", func(t *testing.T) {
+		var cfg struct {
+			Name    string          `ssm:"name" default:"default"`
+			Flags   map[string]bool `ssm:"feature-flags/,path" default:"fallback:true"`
+			Missing string          `ssm:"missing"`
+		}
+
+		src := New("/svc/", &mockSsm{
+			params:  map[string]string{"/svc/name": "service"},
+			pathErr: errors.New("path error"),
+		})
+		src.Strict = true
+
+		err := config.Process(&cfg, src)
+
+		// the path error doesn't hide other errors or prevent other fields from being set
+		var invalidErr *InvalidParametersError
+		assert.True(t, errors.As(err, &invalidErr))
+		assert.Contains(t, err.Error(), "path error")
+		assert.Equal(t, "service", cfg.Name)
+		assert.Equal(t, map[string]bool{"fallback": true}, cfg.Flags)
+	})
+}
+
+func TestSource_ResolveKey(t *testing.T) {
+	source := New("/test/prefix/", nil)
+
+	assert.Equal(t, "/test/prefix/key", source.ResolveKey("KEY"))
+	assert.Equal(t, "/test/prefix/key", source.ResolveKey("/test/prefix/key"))
+	assert.Equal(t, "/other/key", source.ResolveKey("/other/key,absolute"))
+	assert.Equal(t, "/other/key", source.ResolveKey("/Other/Key, ABSOLUTE"))
+
+	// by default only the name is lowered, the prefix is used as written
+	source = New("/MyApp/", nil)
+	assert.Equal(t, "/MyApp/key", source.ResolveKey("Key"))
+
+	source = New("/Test/Prefix/", nil)
+	source.NameCase = LowerCase
+	assert.Equal(t, "/test/prefix/key", source.ResolveKey("/TEST/PREFIX/KEY"))
+
+	source.NameCase = PreserveCase
+	assert.Equal(t, "/Test/Prefix/DB_Password", source.ResolveKey("DB_Password"))
+	assert.Equal(t, "/Test/Prefix/DB_Password", source.ResolveKey("/Test/Prefix/DB_Password"))
+	assert.Equal(t, "/Other/Key", source.ResolveKey("/Other/Key,Absolute"))
+
+	source.NameCase = PreserveCase
+	assert.Equal(t, "/Test/Prefix/Key:Prod", source.ResolveKey("Key, Label=Prod"))
+	assert.Equal(t, "/Test/Prefix/Key:7", source.ResolveKey("Key:7"))
+
+	source.NameCase = strings.ToUpper
+	assert.Equal(t, "/TEST/PREFIX/DB_PASSWORD", source.ResolveKey("db_password"))
+}
+
+func TestSource_ProcessNameCase(t *testing.T) {
+	mock := &mockSsm{
+		params: map[string]string{
+			"/Svc/DB_Password":           "secret",
+			"/Svc/Feature-Flags/NewFlow": "true",
+			"/Other/ApiKey":              "key",
+			"/svc/db_password":           "lower",
+			"/Svc/db_password":           "mixed-prefix",
+		},
+	}
+
+	var cfg struct {
+		Password string          `ssm:"DB_Password"`
+		APIKey   string          `ssm:"/Other/ApiKey,absolute"`
+		Flags    map[string]bool `ssm:"Feature-Flags/,path"`
+	}
+
+	src := New("/Svc/", mock)
+	src.NameCase = PreserveCase
+
+	assert.NoError(t, config.Process(&cfg, src))
+	assert.Equal(t, "secret", cfg.Password)
+	assert.Equal(t, "key", cfg.APIKey)
+	assert.Equal(t, map[string]bool{"NewFlow": true}, cfg.Flags)
+
+	// by default names are lowered and the prefix is used as written
+	var lower struct {
+		Password string `ssm:"DB_Password"`
+	}
+
+	assert.NoError(t, config.Process(&lower, New("/Svc/", mock)))
+	assert.Equal(t, "mixed-prefix", lower.Password)
+
+	src = New("/Svc/", mock)
+	src.NameCase = LowerCase
+
+	assert.NoError(t, config.Process(&lower, src))
+	assert.Equal(t, "lower", lower.Password)
+}
@@ -0,0 +1,348 @@
+package ssm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ ParamStore     = new(MemoryStore)
+	_ PathParamStore = new(MemoryStore)
+	_ ParamStore     = new(FileStore)
+)
+
+// maxPathResults is the maximum number of parameters returned by a single GetParametersByPath call
+const maxPathResults = 10
+
+// StoredParameter is a parameter held by a MemoryStore. In files, a parameter may be written as either an object
+// with these fields or a plain string, which is stored as a String parameter
+type StoredParameter struct {
+	Value string `json:"value" yaml:"value"`
+
+	// Type is the type of the parameter, String if empty
+	Type types.ParameterType `json:"type" yaml:"type"`
+
+	// Version is the version of the parameter, 1 if zero. Only the stored version can be selected, i.e. /svc/key:3
+	Version int64 `json:"version" yaml:"version"`
+
+	// Labels are the labels attached to the stored version, which can be selected, i.e. /svc/key:prod
+	Labels []string `json:"labels" yaml:"labels"`
+}
+
+// UnmarshalJSON allows parameters to be written as plain strings
+func (p *StoredParameter) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		*p = StoredParameter{Value: value}
+		return nil
+	}
+
+	type plain StoredParameter
+	return json.Unmarshal(b, (*plain)(p))
+}
+
+// UnmarshalYAML allows parameters to be written as plain strings
+func (p *StoredParameter) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = StoredParameter{Value: node.Value}
+		return nil
+	}
+
+	type plain StoredParameter
+	return node.Decode((*plain)(p))
+}
+
+// normalise fills in the default type and version, returning an error for unknown types
+func (p StoredParameter) normalise() (StoredParameter, error) {
+	switch p.Type {
+	case "":
+		p.Type = types.ParameterTypeString
+	case types.ParameterTypeString, types.ParameterTypeStringList, types.ParameterTypeSecureString:
+	default:
+		return p, fmt.Errorf("unknown parameter type %s", p.Type)
+	}
+
+	if p.Version == 0 {
+		p.Version = 1
+	}
+
+	return p, nil
+}
+
+// selects returns true if the selector matches the version or one of the labels of the parameter
+func (p StoredParameter) selects(selector string) bool {
+	if selector == "" {
+		return true
+	}
+
+	if version, err := strconv.ParseInt(selector, 10, 64); err == nil {
+		return version == p.Version
+	}
+
+	for _, label := range p.Labels {
+		if label == selector {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiError mimics the errors returned by the parameter store API
+type apiError struct {
+	code, message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+// ErrorCode returns the error code of the error, i.e. ValidationException
+func (e *apiError) ErrorCode() string {
+	return e.code
+}
+
+// ErrorMessage returns the message of the error
+func (e *apiError) ErrorMessage() string {
+	return e.message
+}
+
+// MemoryStore is a ParamStore that holds parameters in memory, for use in development and tests. It behaves like
+// the parameter store API, limiting the number of names per request and returning the names of parameters that
+// do not exist as InvalidParameters
+type MemoryStore struct {
+	mu     sync.RWMutex
+	params map[string]StoredParameter
+}
+
+// NewMemoryStore creates a new store holding the given parameters, keyed by name
+func NewMemoryStore(params map[string]StoredParameter) (*MemoryStore, error) {
+	m := &MemoryStore{}
+	if err := m.replace(params); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// replace replaces every parameter in the store
+func (m *MemoryStore) replace(params map[string]StoredParameter) error {
+	normalised := make(map[string]StoredParameter, len(params))
+
+	for name, p := range params {
+		p, err := p.normalise()
+		if err != nil {
+			return errors.Wrapf(err, "error storing parameter %s", name)
+		}
+
+		normalised[name] = p
+	}
+
+	m.mu.Lock()
+	m.params = normalised
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Put stores a parameter, replacing any existing parameter with the same name. If the version is not set it is
+// one more than the version of the replaced parameter
+func (m *MemoryStore) Put(name string, p StoredParameter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p.Version == 0 {
+		p.Version = m.params[name].Version + 1
+	}
+
+	p, err := p.normalise()
+	if err != nil {
+		return errors.Wrapf(err, "error storing parameter %s", name)
+	}
+
+	if m.params == nil {
+		m.params = make(map[string]StoredParameter)
+	}
+
+	m.params[name] = p
+	return nil
+}
+
+// Delete removes a parameter from the store
+func (m *MemoryStore) Delete(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.params, name)
+}
+
+func (m *MemoryStore) parameter(name, selector string, p StoredParameter) types.Parameter {
+	param := types.Parameter{
+		ARN:      aws.String(fmt.Sprintf("arn:aws:ssm:local:000000000000:parameter%s", name)),
+		DataType: aws.String("text"),
+		Name:     aws.String(name),
+		Type:     p.Type,
+		Value:    aws.String(p.Value),
+		Version:  p.Version,
+	}
+
+	if selector != "" {
+		param.Selector = aws.String(":" + selector)
+	}
+
+	return param
+}
+
+// GetParameters returns the named parameters, which may include a version or label selector
+func (m *MemoryStore) GetParameters(_ context.Context, in *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	if len(in.Names) == 0 || len(in.Names) > batchSize {
+		return nil, &apiError{
+			code:    "ValidationException",
+			message: fmt.Sprintf("between 1 and %d names must be requested, got %d", batchSize, len(in.Names)),
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := &ssm.GetParametersOutput{}
+
+	for _, n := range in.Names {
+		name, selector := splitSelector(n)
+
+		p, ok := m.params[name]
+		if !ok || !p.selects(selector) {
+			out.InvalidParameters = append(out.InvalidParameters, n)
+			continue
+		}
+
+		out.Parameters = append(out.Parameters, m.parameter(name, selector, p))
+	}
+
+	return out, nil
+}
+
+// GetParametersByPath returns the parameters beneath the path in order of name, paginated via NextToken
+func (m *MemoryStore) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	path := aws.ToString(in.Path)
+	if !strings.HasPrefix(path, "/") || in.MaxResults < 0 || in.MaxResults > maxPathResults {
+		return nil, &apiError{
+			code:    "ValidationException",
+			message: fmt.Sprintf("invalid path %q or max results %d", path, in.MaxResults),
+		}
+	}
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.params))
+	for name := range m.params {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		// without recursion only the parameters directly beneath the path are returned
+		if !in.Recursive && strings.Contains(name[len(prefix):], "/") {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	start := 0
+	if in.NextToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*in.NextToken); err != nil || start < 0 || start > len(names) {
+			return nil, &apiError{code: "InvalidNextToken", message: "the next token is not valid"}
+		}
+	}
+
+	limit := int(in.MaxResults)
+	if limit == 0 {
+		limit = maxPathResults
+	}
+
+	out := &ssm.GetParametersByPathOutput{}
+
+	end := start + limit
+	if end < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(names)
+	}
+
+	for _, name := range names[start:end] {
+		out.Parameters = append(out.Parameters, m.parameter(name, "", m.params[name]))
+	}
+
+	return out, nil
+}
+
+// FileStore is a ParamStore backed by a json or yaml file of parameters keyed by name, for running services locally
+// without access to AWS. Files ending in .yaml or .yml are decoded as yaml, any other file as json, i.e.
+//
+//	/svc/db/host: localhost
+//	/svc/db/password:
+//	  value: secret
+//	  type: SecureString
+//	  version: 3
+//	  labels: [prod]
+type FileStore struct {
+	*MemoryStore
+
+	Path string
+}
+
+// NewFileStore creates a new store holding the parameters in the file at path
+func NewFileStore(path string) (*FileStore, error) {
+	f := &FileStore{
+		MemoryStore: &MemoryStore{},
+		Path:        path,
+	}
+
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload replaces the parameters in the store with the current contents of the file
+func (f *FileStore) Reload() error {
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return errors.Wrap(err, "error reading parameter file")
+	}
+
+	params := make(map[string]StoredParameter)
+
+	switch strings.ToLower(filepath.Ext(f.Path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &params)
+	default:
+		err = json.Unmarshal(data, &params)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error decoding parameter file %s", f.Path)
+	}
+
+	return f.replace(params)
+}
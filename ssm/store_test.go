@@ -0,0 +1,214 @@
+package ssm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/onetwentyseven-dev/go-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_GetParameters(t *testing.T) {
+	store, err := NewMemoryStore(map[string]StoredParameter{
+		"/svc/host":     {Value: "localhost"},
+		"/svc/password": {Value: "secret", Type: types.ParameterTypeSecureString, Version: 3, Labels: []string{"prod"}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out, err := store.GetParameters(context.Background(), &ssm.GetParametersInput{
+		Names: []string{"/svc/host", "/svc/password:prod", "/svc/password:2", "/svc/missing"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"/svc/password:2", "/svc/missing"}, out.InvalidParameters)
+	if assert.Len(t, out.Parameters, 2) {
+		assert.Equal(t, "/svc/host", aws.ToString(out.Parameters[0].Name))
+		assert.Equal(t, "localhost", aws.ToString(out.Parameters[0].Value))
+		assert.Equal(t, types.ParameterTypeString, out.Parameters[0].Type)
+		assert.Equal(t, int64(1), out.Parameters[0].Version)
+		assert.Nil(t, out.Parameters[0].Selector)
+
+		assert.Equal(t, "/svc/password", aws.ToString(out.Parameters[1].Name))
+		assert.Equal(t, ":prod", aws.ToString(out.Parameters[1].Selector))
+		assert.Equal(t, types.ParameterTypeSecureString, out.Parameters[1].Type)
+		assert.Equal(t, int64(3), out.Parameters[1].Version)
+	}
+
+	names := make([]string, 11)
+	for i := range names {
+		names[i] = fmt.Sprintf("/svc/key-%d", i)
+	}
+
+	_, err = store.GetParameters(context.Background(), &ssm.GetParametersInput{Names: names})
+	var apiErr interface{ ErrorCode() string }
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "ValidationException", apiErr.ErrorCode())
+	}
+
+	_, err = NewMemoryStore(map[string]StoredParameter{"/svc/key": {Type: "Unknown"}})
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_Put(t *testing.T) {
+	store, err := NewMemoryStore(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, store.Put("/svc/key", StoredParameter{Value: "one"}))
+	assert.NoError(t, store.Put("/svc/key", StoredParameter{Value: "two"}))
+
+	out, err := store.GetParameters(context.Background(), &ssm.GetParametersInput{Names: []string{"/svc/key:2"}})
+	if assert.NoError(t, err) && assert.Len(t, out.Parameters, 1) {
+		assert.Equal(t, "two", aws.ToString(out.Parameters[0].Value))
+	}
+
+	store.Delete("/svc/key")
+
+	out, err = store.GetParameters(context.Background(), &ssm.GetParametersInput{Names: []string{"/svc/key"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"/svc/key"}, out.InvalidParameters)
+	}
+}
+
+func TestMemoryStore_GetParametersByPath(t *testing.T) {
+	params := make(map[string]StoredParameter)
+	for i := 0; i < 12; i++ {
+		params[fmt.Sprintf("/svc/flags/flag-%02d", i)] = StoredParameter{Value: "true"}
+	}
+	params["/svc/flags/nested/flag"] = StoredParameter{Value: "false"}
+	params["/svc/other"] = StoredParameter{Value: "other"}
+
+	store, err := NewMemoryStore(params)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	getAll := func(in *ssm.GetParametersByPathInput) ([]string, int) {
+		var (
+			names []string
+			pages int
+		)
+
+		for {
+			out, err := store.GetParametersByPath(context.Background(), in)
+			if !assert.NoError(t, err) {
+				return nil, 0
+			}
+
+			pages++
+			for _, p := range out.Parameters {
+				names = append(names, aws.ToString(p.Name))
+			}
+
+			if out.NextToken == nil {
+				return names, pages
+			}
+
+			in.NextToken = out.NextToken
+		}
+	}
+
+	names, pages := getAll(&ssm.GetParametersByPathInput{Path: aws.String("/svc/flags/"), Recursive: true})
+	assert.Len(t, names, 13)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, "/svc/flags/nested/flag", names[12])
+
+	names, pages = getAll(&ssm.GetParametersByPathInput{Path: aws.String("/svc/flags"), MaxResults: 5})
+	assert.Len(t, names, 12)
+	assert.Equal(t, 3, pages)
+
+	_, err = store.GetParametersByPath(context.Background(), &ssm.GetParametersByPathInput{Path: aws.String("svc")})
+	assert.Error(t, err)
+
+	_, err = store.GetParametersByPath(context.Background(), &ssm.GetParametersByPathInput{
+		Path:       aws.String("/svc"),
+		MaxResults: 11,
+	})
+	assert.Error(t, err)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssm")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"params.json": `{
+	"/svc/host": "localhost",
+	"/svc/port": {"value": "5432", "version": 2},
+	"/svc/password": {"value": "secret", "type": "SecureString", "labels": ["prod"]},
+	"/svc/flags/new-checkout": "true"
+}`,
+		"params.yaml": `
+/svc/host: localhost
+/svc/port:
+  value: "5432"
+  version: 2
+/svc/password:
+  value: secret
+  type: SecureString
+  labels: [prod]
+/svc/flags/new-checkout: "true"
+`,
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+
+			store, err := NewFileStore(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var cfg struct {
+				Host     string          `ssm:"host"`
+				Port     int             `ssm:"port:2"`
+				Password string          `ssm:"password,label=prod"`
+				Flags    map[string]bool `ssm:"flags/,path"`
+				Missing  string          `ssm:"missing" default:"default"`
+			}
+
+			assert.NoError(t, config.Process(&cfg, New("/svc/", store)))
+			assert.Equal(t, "localhost", cfg.Host)
+			assert.Equal(t, 5432, cfg.Port)
+			assert.Equal(t, "secret", cfg.Password)
+			assert.Equal(t, map[string]bool{"new-checkout": true}, cfg.Flags)
+			assert.Equal(t, "default", cfg.Missing)
+
+			// invalid names are reported like the real api
+			src := New("/svc/", store)
+			src.Strict = true
+			assert.Error(t, config.Process(&cfg, src))
+
+			assert.NoError(t, ioutil.WriteFile(path, []byte(`{"/svc/host": "changed"}`), 0600))
+			assert.NoError(t, store.Reload())
+			assert.NoError(t, config.Process(&cfg, New("/svc/", store)))
+			assert.Equal(t, "changed", cfg.Host)
+		})
+	}
+
+	_, err = NewFileStore(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	assert.NoError(t, ioutil.WriteFile(invalid, []byte("/svc/key: {type: Unknown}"), 0600))
+	_, err = NewFileStore(invalid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"io"
+	"text/tabwriter"
+	"text/template"
+)
+
+// KeyResolver is implemented by sources that transform tag values before looking them up, i.e. by applying a
+// prefix, so that the key that is actually looked up can be reported
+type KeyResolver interface {
+	ResolveKey(tagValue string) string
+}
+
+// UsageKey is the key a field is looked up with in a single source
+type UsageKey struct {
+	// Source is the tag key of the source
+	Source string
+	// Key is the key the source looks up, after any prefix or casing rules have been applied
+	Key string
+}
+
+// UsageField describes a single field, used for generating usage output
+type UsageField struct {
+	Field, Path string
+
+	// Keys holds the keys the field is looked up with, in order of precedence
+	Keys []UsageKey
+
+	Type string
+	// Default is the value of the default tag, redacted for secret fields
+	Default     string
+	Required    bool
+	Secret      bool
+	Description string
+}
+
+var defaultUsageTemplate = template.Must(template.New("usage").Parse(
+	"FIELD\tKEYS\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION\n" +
+		"{{ range . }}" +
+		"{{ .Path }}\t" +
+		"{{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Source }}:{{ $k.Key }}{{ end }}\t" +
+		"{{ .Type }}\t{{ .Default }}\t{{ .Required }}\t{{ .Description }}\n" +
+		"{{ end }}",
+))
+
+// Usage writes a table describing every field of params that can be loaded from one of the sources
+func Usage(w io.Writer, params interface{}, sources ...Source) error {
+	return New().Usage(w, params, sources...)
+}
+
+// UsageTemplate executes the template with a []UsageField describing every field of params that can be loaded
+// from one of the sources
+func UsageTemplate(w io.Writer, tmpl *template.Template, params interface{}, sources ...Source) error {
+	return New().UsageTemplate(w, tmpl, params, sources...)
+}
+
+// Usage writes a table describing every field of params that can be loaded from one of the sources. Any errors
+// found while walking params are returned after the table is written
+func (p *Processor) Usage(w io.Writer, params interface{}, sources ...Source) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	err := p.UsageTemplate(tw, defaultUsageTemplate, params, sources...)
+	if flushErr := tw.Flush(); flushErr != nil {
+		return flushErr
+	}
+
+	return err
+}
+
+// UsageTemplate executes the template with a []UsageField describing every field of params that can be loaded
+// from one of the sources. Any errors found while walking params are returned after the template is executed
+func (p *Processor) UsageTemplate(w io.Writer, tmpl *template.Template, params interface{}, sources ...Source) error {
+	fields, walkErr := p.usageFields(params, sources)
+
+	if err := tmpl.Execute(w, fields); err != nil {
+		return err
+	}
+
+	return walkErr
+}
+
+func (p *Processor) usageFields(params interface{}, sources []Source) ([]UsageField, error) {
+	ps, _, err := p.newProcessState(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.process(params, "", nil)
+
+	fields := make([]UsageField, len(ps.fields))
+	for i, f := range ps.fields {
+		keys := make([]UsageKey, len(f.params))
+		for j, param := range f.params {
+			keys[j] = UsageKey{
+				Source: param.tagKey,
+				Key:    ps.resolveKey(param),
+			}
+		}
+
+		defaultValue := f.defaultValue
+		if f.secret && defaultValue != "" {
+			defaultValue = redacted
+		}
+
+		fields[i] = UsageField{
+			Field:       f.name,
+			Path:        f.path,
+			Keys:        keys,
+			Type:        f.typ.String(),
+			Default:     defaultValue,
+			Required:    f.required,
+			Secret:      f.secret,
+			Description: f.description,
+		}
+	}
+
+	return fields, ps.errs.errorOrNil()
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type usageParams struct {
+	Host    string        `env:"host" mock:"host" required:"true" desc:"the host to connect to"`
+	Port    int           `env:"port" default:"8080" desc:"the port to listen on"`
+	Timeout time.Duration `mock:"timeout"`
+	Ignored string        `env:"ignored" ignore:"true"`
+	Nested  struct {
+		Labels map[string]string `env:"labels"`
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Usage(&buf, &usageParams{}, &EnvSource{Prefix: "app_"}, &mockSource{tagKey: "mock"})
+	assert.NoError(t, err)
+
+	expected := "" +
+		"FIELD          KEYS                     TYPE               DEFAULT  REQUIRED  DESCRIPTION\n" +
+		"Host           env:APP_HOST, mock:host  string                      true      the host to connect to\n" +
+		"Port           env:APP_PORT             int                8080     false     the port to listen on\n" +
+		"Timeout        mock:timeout             time.Duration               false     \n" +
+		"Nested.Labels  env:APP_LABELS           map[string]string           false     \n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestUsageTemplate(t *testing.T) {
+	var buf bytes.Buffer
+
+	tmpl := template.Must(template.New("test").Parse(
+		"{{ range . }}{{ .Path }}={{ (index .Keys 0).Key }}\n{{ end }}",
+	))
+
+	err := New(WithPrecedence("mock")).UsageTemplate(&buf, tmpl, &usageParams{}, &EnvSource{}, &mockSource{tagKey: "mock"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Host=host\nPort=PORT\nTimeout=timeout\nNested.Labels=LABELS\n", buf.String())
+}
+
+func TestUsage_WalkError(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Usage(&buf, &struct {
+		Valid       string   `env:"valid"`
+		Unsupported chan int `env:"unsupported"`
+	}{})
+
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+	assert.Contains(t, buf.String(), "Valid")
+	assert.NotContains(t, buf.String(), "Unsupported")
+}
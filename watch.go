@@ -0,0 +1,223 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeFunc is called when a reload changes the value of one or more fields. It is passed the old and new
+// values, both pointers to the same type as the params passed to Watch, and the paths of the changed fields
+type ChangeFunc func(oldValue, newValue interface{}, changed []string)
+
+// WatchOptions configures how a Watcher reloads values
+type WatchOptions struct {
+	// Interval is how often the values are reloaded, a zero interval disables periodic reloads
+	Interval time.Duration
+
+	// Trigger causes a reload every time a value is received on it
+	Trigger <-chan struct{}
+
+	// OnChange is called, in order, every time a reload changes the value of one or more fields
+	OnChange []ChangeFunc
+
+	// OnError is called when a reload fails, in which case the current value is kept
+	OnError func(error)
+
+	// Processor is used to process values, if nil a Processor with default options is used
+	Processor *Processor
+}
+
+// Watcher periodically reloads values from a set of sources. Every reload processes values into a fresh copy of
+// the params, so values returned by Load are never modified and can be safely shared between goroutines
+type Watcher struct {
+	typ     reflect.Type
+	tmpl    reflect.Value
+	opts    WatchOptions
+	sources []Source
+
+	value atomic.Value
+	mu    sync.Mutex
+	done  chan struct{}
+}
+
+// Watch loads values from the sources into a copy of params, which must be a pointer to a struct, and reloads
+// them whenever the interval elapses or the trigger fires until the context is done. The params themselves are
+// only used as a template for every reload and are never modified. An error is returned if the initial load fails
+func Watch(ctx context.Context, params interface{}, opts WatchOptions, sources ...Source) (*Watcher, error) {
+	if opts.Processor == nil {
+		opts.Processor = New()
+	}
+
+	w := &Watcher{
+		typ:     reflect.TypeOf(params).Elem(),
+		tmpl:    reflect.ValueOf(params).Elem(),
+		opts:    opts,
+		sources: sources,
+		done:    make(chan struct{}),
+	}
+
+	val, err := w.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.value.Store(val)
+
+	go w.run(ctx)
+
+	return w, nil
+}
+
+// Load returns the current value, a pointer to the same type as the params passed to Watch. The returned value
+// must not be modified
+func (w *Watcher) Load() interface{} {
+	return w.value.Load()
+}
+
+// Done returns a channel that is closed once the watcher stops reloading
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Reload reloads the values immediately, calling any change callbacks if values changed. If the reload fails
+// the current value is kept and the error is returned
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	val, err := w.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	old := w.value.Load()
+	changed := w.opts.Processor.diff(reflect.ValueOf(old).Elem(), reflect.ValueOf(val).Elem(), "")
+	if len(changed) == 0 {
+		return nil
+	}
+
+	w.value.Store(val)
+
+	for _, fn := range w.opts.OnChange {
+		fn(old, val, changed)
+	}
+
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if w.opts.Interval > 0 {
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	trigger := w.opts.Trigger
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case _, ok := <-trigger:
+			if !ok {
+				// a closed trigger would fire continuously, stop listening to it
+				trigger = nil
+				continue
+			}
+		}
+
+		if err := w.Reload(ctx); err != nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+	}
+}
+
+// load processes the values into a fresh copy of the template
+func (w *Watcher) load(ctx context.Context) (interface{}, error) {
+	val := reflect.New(w.typ)
+	val.Elem().Set(cloneStruct(w.tmpl))
+
+	if err := w.opts.Processor.ProcessContext(ctx, val.Interface(), w.sources...); err != nil {
+		return nil, err
+	}
+
+	return val.Interface(), nil
+}
+
+// cloneStruct returns a copy of a struct, also copying any structs referenced via pointers or interfaces, since
+// processing sets values on those in place
+func cloneStruct(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	for i := 0; i < c.NumField(); i++ {
+		f := c.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+
+		switch {
+		case f.Kind() == reflect.Struct:
+			f.Set(cloneStruct(f))
+		case f.Kind() == reflect.Ptr && !f.IsNil() && f.Elem().Kind() == reflect.Struct:
+			p := reflect.New(f.Elem().Type())
+			p.Elem().Set(cloneStruct(f.Elem()))
+			f.Set(p)
+		case f.Kind() == reflect.Interface && !f.IsNil() && f.Elem().Kind() == reflect.Ptr &&
+			f.Elem().Elem().Kind() == reflect.Struct:
+			p := reflect.New(f.Elem().Elem().Type())
+			p.Elem().Set(cloneStruct(f.Elem().Elem()))
+			f.Set(p)
+		}
+	}
+
+	return c
+}
+
+// diff returns the paths of the fields that differ between two values of the same struct type
+func (p *Processor) diff(oldValue, newValue reflect.Value, path string) []string {
+	var changed []string
+
+	opts := setterOptions{parsers: p.parsers}
+	typ := oldValue.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		fieldPath := path + sf.Name
+
+		o, n := oldValue.Field(i), newValue.Field(i)
+
+		// unwrap interfaces and pointers to structs, comparing them as structs if both are set
+		if o.Kind() == reflect.Interface && !o.IsNil() && !n.IsNil() && o.Elem().Type() == n.Elem().Type() {
+			o, n = o.Elem(), n.Elem()
+		}
+
+		if o.Kind() == reflect.Ptr && !o.IsNil() && !n.IsNil() && o.Elem().Kind() == reflect.Struct &&
+			!opts.hasCustomSetter(o.Elem().Type()) {
+			o, n = o.Elem(), n.Elem()
+		}
+
+		if o.Kind() == reflect.Struct && !opts.hasCustomSetter(o.Type()) {
+			changed = append(changed, p.diff(o, n, fieldPath+".")...)
+			continue
+		}
+
+		if !o.CanInterface() {
+			continue
+		}
+
+		if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+			changed = append(changed, fieldPath)
+		}
+	}
+
+	return changed
+}
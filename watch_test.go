@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type watchSource struct {
+	mu   sync.Mutex
+	vars map[string]string
+	err  error
+}
+
+func (w *watchSource) TagKey() string {
+	return "watch"
+}
+
+func (w *watchSource) set(key, value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.vars[key] = value
+}
+
+func (w *watchSource) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.err = err
+}
+
+func (w *watchSource) Process(input map[string][]Parameter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	for k, params := range input {
+		for _, p := range params {
+			if err := p.SetValue(w.vars[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type watchParams struct {
+	Host   string `watch:"host"`
+	Port   int    `watch:"port"`
+	Nested *struct {
+		Timeout time.Duration `watch:"timeout"`
+	}
+}
+
+func TestWatch(t *testing.T) {
+	src := &watchSource{
+		vars: map[string]string{
+			"host":    "localhost",
+			"port":    "8080",
+			"timeout": "1s",
+		},
+	}
+
+	type change struct {
+		old, new *watchParams
+		changed  []string
+	}
+
+	changes := make(chan change, 1)
+	errs := make(chan error, 1)
+	trigger := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpl := &watchParams{}
+	w, err := Watch(ctx, tmpl, WatchOptions{
+		Trigger: trigger,
+		OnChange: []ChangeFunc{func(oldValue, newValue interface{}, changed []string) {
+			changes <- change{oldValue.(*watchParams), newValue.(*watchParams), changed}
+		}},
+		OnError: func(err error) {
+			errs <- err
+		},
+	}, src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	initial := w.Load().(*watchParams)
+	assert.Equal(t, "localhost", initial.Host)
+	assert.Equal(t, 8080, initial.Port)
+	assert.Equal(t, time.Second, initial.Nested.Timeout)
+	assert.Equal(t, &watchParams{}, tmpl)
+
+	src.set("port", "9090")
+	src.set("timeout", "2s")
+	trigger <- struct{}{}
+
+	select {
+	case c := <-changes:
+		assert.Equal(t, []string{"Port", "Nested.Timeout"}, c.changed)
+		assert.Same(t, initial, c.old)
+		assert.Equal(t, 9090, c.new.Port)
+		assert.Equal(t, 2*time.Second, c.new.Nested.Timeout)
+		assert.Same(t, c.new, w.Load())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	// the previous value must not be modified by a reload
+	assert.Equal(t, 8080, initial.Port)
+	assert.Equal(t, time.Second, initial.Nested.Timeout)
+
+	// errors keep the current value
+	src.setErr(errors.New("test error"))
+	current := w.Load()
+	trigger <- struct{}{}
+
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "1 error occurred:\n\t* test error\n\n")
+		assert.Same(t, current, w.Load())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	// reloads without changes don't call the callbacks
+	src.setErr(nil)
+	assert.NoError(t, w.Reload(ctx))
+	assert.Len(t, changes, 0)
+
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for watcher to stop")
+	}
+}
+
+func TestWatch_Interval(t *testing.T) {
+	src := &watchSource{
+		vars: map[string]string{
+			"host": "localhost",
+		},
+	}
+
+	changed := make(chan []string, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := Watch(ctx, &watchParams{}, WatchOptions{
+		Interval: 10 * time.Millisecond,
+		OnChange: []ChangeFunc{func(_, _ interface{}, c []string) {
+			changed <- c
+		}},
+	}, src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	src.set("host", "example.com")
+
+	select {
+	case c := <-changed:
+		assert.Equal(t, []string{"Host"}, c)
+		assert.Equal(t, "example.com", w.Load().(*watchParams).Host)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+}
+
+func TestWatch_InitialError(t *testing.T) {
+	src := &watchSource{err: errors.New("test error")}
+
+	w, err := Watch(context.Background(), &watchParams{}, WatchOptions{}, src)
+	assert.Error(t, err)
+	assert.Nil(t, w)
+}